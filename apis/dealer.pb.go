@@ -24,24 +24,46 @@ var File_apis_dealer_proto protoreflect.FileDescriptor
 var file_apis_dealer_proto_rawDesc = []byte{
 	0x0a, 0x11, 0x61, 0x70, 0x69, 0x73, 0x2f, 0x64, 0x65, 0x61, 0x6c, 0x65, 0x72, 0x2e, 0x70, 0x72,
 	0x6f, 0x74, 0x6f, 0x1a, 0x12, 0x61, 0x70, 0x69, 0x73, 0x2f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
-	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x32, 0x38, 0x0a, 0x06, 0x44, 0x65, 0x61, 0x6c, 0x65,
-	0x72, 0x12, 0x2e, 0x0a, 0x07, 0x47, 0x65, 0x74, 0x44, 0x65, 0x61, 0x6c, 0x12, 0x0f, 0x2e, 0x47,
-	0x65, 0x74, 0x44, 0x65, 0x61, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e, 0x2e,
-	0x47, 0x65, 0x74, 0x44, 0x65, 0x61, 0x6c, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x22, 0x00, 0x30,
-	0x01, 0x42, 0x1f, 0x5a, 0x1d, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
-	0x61, 0x74, 0x67, 0x61, 0x6e, 0x65, 0x2f, 0x6f, 0x70, 0x65, 0x6e, 0x74, 0x64, 0x2f, 0x61, 0x70,
-	0x69, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x32, 0xd2, 0x01, 0x0a, 0x06, 0x44, 0x65, 0x61, 0x6c,
+	0x65, 0x72, 0x12, 0x2e, 0x0a, 0x07, 0x47, 0x65, 0x74, 0x44, 0x65, 0x61, 0x6c, 0x12, 0x0f, 0x2e,
+	0x47, 0x65, 0x74, 0x44, 0x65, 0x61, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0e,
+	0x2e, 0x47, 0x65, 0x74, 0x44, 0x65, 0x61, 0x6c, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x22, 0x00,
+	0x30, 0x01, 0x12, 0x3a, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x53, 0x74,
+	0x61, 0x74, 0x75, 0x73, 0x12, 0x16, 0x2e, 0x47, 0x65, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0c, 0x2e, 0x4f,
+	0x72, 0x64, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x00, 0x30, 0x01, 0x12, 0x26,
+	0x0a, 0x08, 0x47, 0x65, 0x74, 0x44, 0x65, 0x70, 0x74, 0x68, 0x12, 0x10, 0x2e, 0x47, 0x65, 0x74,
+	0x44, 0x65, 0x70, 0x74, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x06, 0x2e, 0x44,
+	0x65, 0x70, 0x74, 0x68, 0x22, 0x00, 0x12, 0x34, 0x0a, 0x0b, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d,
+	0x44, 0x65, 0x70, 0x74, 0x68, 0x12, 0x13, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x44, 0x65,
+	0x70, 0x74, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0c, 0x2e, 0x44, 0x65, 0x70,
+	0x74, 0x68, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x22, 0x00, 0x30, 0x01, 0x42, 0x1f, 0x5a, 0x1d,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x61, 0x74, 0x67, 0x61, 0x6e,
+	0x65, 0x2f, 0x6f, 0x70, 0x65, 0x6e, 0x74, 0x64, 0x2f, 0x61, 0x70, 0x69, 0x73, 0x62, 0x06, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var file_apis_dealer_proto_goTypes = []interface{}{
-	(*GetDealRequest)(nil), // 0: GetDealRequest
-	(*GetDealStream)(nil),  // 1: GetDealStream
+	(*GetDealRequest)(nil),        // 0: GetDealRequest
+	(*GetOrderStatusRequest)(nil), // 1: GetOrderStatusRequest
+	(*GetDepthRequest)(nil),       // 2: GetDepthRequest
+	(*StreamDepthRequest)(nil),    // 3: StreamDepthRequest
+	(*GetDealStream)(nil),         // 4: GetDealStream
+	(*OrderStatus)(nil),           // 5: OrderStatus
+	(*Depth)(nil),                 // 6: Depth
+	(*DepthUpdate)(nil),           // 7: DepthUpdate
 }
 var file_apis_dealer_proto_depIdxs = []int32{
 	0, // 0: Dealer.GetDeal:input_type -> GetDealRequest
-	1, // 1: Dealer.GetDeal:output_type -> GetDealStream
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
+	1, // 1: Dealer.GetOrderStatus:input_type -> GetOrderStatusRequest
+	2, // 2: Dealer.GetDepth:input_type -> GetDepthRequest
+	3, // 3: Dealer.StreamDepth:input_type -> StreamDepthRequest
+	4, // 4: Dealer.GetDeal:output_type -> GetDealStream
+	5, // 5: Dealer.GetOrderStatus:output_type -> OrderStatus
+	6, // 6: Dealer.GetDepth:output_type -> Depth
+	7, // 7: Dealer.StreamDepth:output_type -> DepthUpdate
+	4, // [4:8] is the sub-list for method output_type
+	0, // [0:4] is the sub-list for method input_type
 	0, // [0:0] is the sub-list for extension type_name
 	0, // [0:0] is the sub-list for extension extendee
 	0, // [0:0] is the sub-list for field type_name
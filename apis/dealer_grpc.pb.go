@@ -19,7 +19,10 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	Dealer_GetDeal_FullMethodName = "/Dealer/GetDeal"
+	Dealer_GetDeal_FullMethodName        = "/Dealer/GetDeal"
+	Dealer_GetOrderStatus_FullMethodName = "/Dealer/GetOrderStatus"
+	Dealer_GetDepth_FullMethodName       = "/Dealer/GetDepth"
+	Dealer_StreamDepth_FullMethodName    = "/Dealer/StreamDepth"
 )
 
 // DealerClient is the client API for Dealer service.
@@ -27,6 +30,9 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type DealerClient interface {
 	GetDeal(ctx context.Context, in *GetDealRequest, opts ...grpc.CallOption) (Dealer_GetDealClient, error)
+	GetOrderStatus(ctx context.Context, in *GetOrderStatusRequest, opts ...grpc.CallOption) (Dealer_GetOrderStatusClient, error)
+	GetDepth(ctx context.Context, in *GetDepthRequest, opts ...grpc.CallOption) (*Depth, error)
+	StreamDepth(ctx context.Context, in *StreamDepthRequest, opts ...grpc.CallOption) (Dealer_StreamDepthClient, error)
 }
 
 type dealerClient struct {
@@ -69,11 +75,87 @@ func (x *dealerGetDealClient) Recv() (*GetDealStream, error) {
 	return m, nil
 }
 
+func (c *dealerClient) GetOrderStatus(ctx context.Context, in *GetOrderStatusRequest, opts ...grpc.CallOption) (Dealer_GetOrderStatusClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Dealer_ServiceDesc.Streams[1], Dealer_GetOrderStatus_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &dealerGetOrderStatusClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Dealer_GetOrderStatusClient interface {
+	Recv() (*OrderStatus, error)
+	grpc.ClientStream
+}
+
+type dealerGetOrderStatusClient struct {
+	grpc.ClientStream
+}
+
+func (x *dealerGetOrderStatusClient) Recv() (*OrderStatus, error) {
+	m := new(OrderStatus)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *dealerClient) GetDepth(ctx context.Context, in *GetDepthRequest, opts ...grpc.CallOption) (*Depth, error) {
+	out := new(Depth)
+	err := c.cc.Invoke(ctx, Dealer_GetDepth_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dealerClient) StreamDepth(ctx context.Context, in *StreamDepthRequest, opts ...grpc.CallOption) (Dealer_StreamDepthClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Dealer_ServiceDesc.Streams[2], Dealer_StreamDepth_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &dealerStreamDepthClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Dealer_StreamDepthClient interface {
+	Recv() (*DepthUpdate, error)
+	grpc.ClientStream
+}
+
+type dealerStreamDepthClient struct {
+	grpc.ClientStream
+}
+
+func (x *dealerStreamDepthClient) Recv() (*DepthUpdate, error) {
+	m := new(DepthUpdate)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // DealerServer is the server API for Dealer service.
 // All implementations must embed UnimplementedDealerServer
 // for forward compatibility
 type DealerServer interface {
 	GetDeal(*GetDealRequest, Dealer_GetDealServer) error
+	GetOrderStatus(*GetOrderStatusRequest, Dealer_GetOrderStatusServer) error
+	GetDepth(context.Context, *GetDepthRequest) (*Depth, error)
+	StreamDepth(*StreamDepthRequest, Dealer_StreamDepthServer) error
 	mustEmbedUnimplementedDealerServer()
 }
 
@@ -84,6 +166,15 @@ type UnimplementedDealerServer struct {
 func (UnimplementedDealerServer) GetDeal(*GetDealRequest, Dealer_GetDealServer) error {
 	return status.Errorf(codes.Unimplemented, "method GetDeal not implemented")
 }
+func (UnimplementedDealerServer) GetOrderStatus(*GetOrderStatusRequest, Dealer_GetOrderStatusServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetOrderStatus not implemented")
+}
+func (UnimplementedDealerServer) GetDepth(context.Context, *GetDepthRequest) (*Depth, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDepth not implemented")
+}
+func (UnimplementedDealerServer) StreamDepth(*StreamDepthRequest, Dealer_StreamDepthServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamDepth not implemented")
+}
 func (UnimplementedDealerServer) mustEmbedUnimplementedDealerServer() {}
 
 // UnsafeDealerServer may be embedded to opt out of forward compatibility for this service.
@@ -118,19 +209,94 @@ func (x *dealerGetDealServer) Send(m *GetDealStream) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Dealer_GetOrderStatus_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetOrderStatusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DealerServer).GetOrderStatus(m, &dealerGetOrderStatusServer{stream})
+}
+
+type Dealer_GetOrderStatusServer interface {
+	Send(*OrderStatus) error
+	grpc.ServerStream
+}
+
+type dealerGetOrderStatusServer struct {
+	grpc.ServerStream
+}
+
+func (x *dealerGetOrderStatusServer) Send(m *OrderStatus) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Dealer_GetDepth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDepthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DealerServer).GetDepth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Dealer_GetDepth_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DealerServer).GetDepth(ctx, req.(*GetDepthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Dealer_StreamDepth_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamDepthRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DealerServer).StreamDepth(m, &dealerStreamDepthServer{stream})
+}
+
+type Dealer_StreamDepthServer interface {
+	Send(*DepthUpdate) error
+	grpc.ServerStream
+}
+
+type dealerStreamDepthServer struct {
+	grpc.ServerStream
+}
+
+func (x *dealerStreamDepthServer) Send(m *DepthUpdate) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // Dealer_ServiceDesc is the grpc.ServiceDesc for Dealer service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
 var Dealer_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "Dealer",
 	HandlerType: (*DealerServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetDepth",
+			Handler:    _Dealer_GetDepth_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "GetDeal",
 			Handler:       _Dealer_GetDeal_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "GetOrderStatus",
+			Handler:       _Dealer_GetOrderStatus_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamDepth",
+			Handler:       _Dealer_StreamDepth_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "apis/dealer.proto",
 }
@@ -24,7 +24,7 @@ var File_apis_frontend_proto protoreflect.FileDescriptor
 var file_apis_frontend_proto_rawDesc = []byte{
 	0x0a, 0x13, 0x61, 0x70, 0x69, 0x73, 0x2f, 0x66, 0x72, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x64, 0x2e,
 	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x12, 0x61, 0x70, 0x69, 0x73, 0x2f, 0x6d, 0x65, 0x73, 0x73,
-	0x61, 0x67, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x32, 0xe3, 0x01, 0x0a, 0x08, 0x46, 0x72,
+	0x61, 0x67, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x32, 0x88, 0x03, 0x0a, 0x08, 0x46, 0x72,
 	0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x64, 0x12, 0x22, 0x0a, 0x03, 0x42, 0x75, 0x79, 0x12, 0x0b, 0x2e,
 	0x42, 0x75, 0x79, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0c, 0x2e, 0x42, 0x75, 0x79,
 	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x25, 0x0a, 0x04, 0x53, 0x65,
@@ -38,38 +38,60 @@ var file_apis_frontend_proto_rawDesc = []byte{
 	0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x2f,
 	0x0a, 0x0a, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x53, 0x65, 0x6c, 0x6c, 0x12, 0x0e, 0x2e, 0x55,
 	0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x55,
-	0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42,
-	0x1f, 0x5a, 0x1d, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x61, 0x74,
-	0x67, 0x61, 0x6e, 0x65, 0x2f, 0x6f, 0x70, 0x65, 0x6e, 0x74, 0x64, 0x2f, 0x61, 0x70, 0x69, 0x73,
-	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12,
+	0x26, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x12, 0x10, 0x2e, 0x47, 0x65,
+	0x74, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x06, 0x2e,
+	0x4f, 0x72, 0x64, 0x65, 0x72, 0x22, 0x00, 0x12, 0x37, 0x0a, 0x0a, 0x4c, 0x69, 0x73, 0x74, 0x4f,
+	0x72, 0x64, 0x65, 0x72, 0x73, 0x12, 0x12, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x4f, 0x72, 0x64, 0x65,
+	0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x13, 0x2e, 0x4c, 0x69, 0x73, 0x74,
+	0x4f, 0x72, 0x64, 0x65, 0x72, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00,
+	0x12, 0x42, 0x0a, 0x12, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x55,
+	0x70, 0x64, 0x61, 0x74, 0x65, 0x73, 0x12, 0x1a, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x4f,
+	0x72, 0x64, 0x65, 0x72, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x0c, 0x2e, 0x4f, 0x72, 0x64, 0x65, 0x72, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65,
+	0x22, 0x00, 0x30, 0x01, 0x42, 0x1f, 0x5a, 0x1d, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63,
+	0x6f, 0x6d, 0x2f, 0x61, 0x74, 0x67, 0x61, 0x6e, 0x65, 0x2f, 0x6f, 0x70, 0x65, 0x6e, 0x74, 0x64,
+	0x2f, 0x61, 0x70, 0x69, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var file_apis_frontend_proto_goTypes = []interface{}{
-	(*BuyRequest)(nil),     // 0: BuyRequest
-	(*SellRequest)(nil),    // 1: SellRequest
-	(*CancelRequest)(nil),  // 2: CancelRequest
-	(*UpdateRequest)(nil),  // 3: UpdateRequest
-	(*BuyResponse)(nil),    // 4: BuyResponse
-	(*SellResponse)(nil),   // 5: SellResponse
-	(*CancelResponse)(nil), // 6: CancelResponse
-	(*UpdateResponse)(nil), // 7: UpdateResponse
+	(*BuyRequest)(nil),                // 0: BuyRequest
+	(*SellRequest)(nil),               // 1: SellRequest
+	(*CancelRequest)(nil),             // 2: CancelRequest
+	(*UpdateRequest)(nil),             // 3: UpdateRequest
+	(*GetOrderRequest)(nil),           // 4: GetOrderRequest
+	(*ListOrdersRequest)(nil),         // 5: ListOrdersRequest
+	(*StreamOrderUpdatesRequest)(nil), // 6: StreamOrderUpdatesRequest
+	(*BuyResponse)(nil),               // 7: BuyResponse
+	(*SellResponse)(nil),              // 8: SellResponse
+	(*CancelResponse)(nil),            // 9: CancelResponse
+	(*UpdateResponse)(nil),            // 10: UpdateResponse
+	(*Order)(nil),                     // 11: Order
+	(*ListOrdersResponse)(nil),        // 12: ListOrdersResponse
+	(*OrderUpdate)(nil),               // 13: OrderUpdate
 }
 var file_apis_frontend_proto_depIdxs = []int32{
-	0, // 0: Frontend.Buy:input_type -> BuyRequest
-	1, // 1: Frontend.Sell:input_type -> SellRequest
-	2, // 2: Frontend.Cancel:input_type -> CancelRequest
-	3, // 3: Frontend.UpdateBuy:input_type -> UpdateRequest
-	3, // 4: Frontend.UpdateSell:input_type -> UpdateRequest
-	4, // 5: Frontend.Buy:output_type -> BuyResponse
-	5, // 6: Frontend.Sell:output_type -> SellResponse
-	6, // 7: Frontend.Cancel:output_type -> CancelResponse
-	7, // 8: Frontend.UpdateBuy:output_type -> UpdateResponse
-	7, // 9: Frontend.UpdateSell:output_type -> UpdateResponse
-	5, // [5:10] is the sub-list for method output_type
-	0, // [0:5] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	0,  // 0: Frontend.Buy:input_type -> BuyRequest
+	1,  // 1: Frontend.Sell:input_type -> SellRequest
+	2,  // 2: Frontend.Cancel:input_type -> CancelRequest
+	3,  // 3: Frontend.UpdateBuy:input_type -> UpdateRequest
+	3,  // 4: Frontend.UpdateSell:input_type -> UpdateRequest
+	4,  // 5: Frontend.GetOrder:input_type -> GetOrderRequest
+	5,  // 6: Frontend.ListOrders:input_type -> ListOrdersRequest
+	6,  // 7: Frontend.StreamOrderUpdates:input_type -> StreamOrderUpdatesRequest
+	7,  // 8: Frontend.Buy:output_type -> BuyResponse
+	8,  // 9: Frontend.Sell:output_type -> SellResponse
+	9,  // 10: Frontend.Cancel:output_type -> CancelResponse
+	10, // 11: Frontend.UpdateBuy:output_type -> UpdateResponse
+	10, // 12: Frontend.UpdateSell:output_type -> UpdateResponse
+	11, // 13: Frontend.GetOrder:output_type -> Order
+	12, // 14: Frontend.ListOrders:output_type -> ListOrdersResponse
+	13, // 15: Frontend.StreamOrderUpdates:output_type -> OrderUpdate
+	8,  // [8:16] is the sub-list for method output_type
+	0,  // [0:8] is the sub-list for method input_type
+	0,  // [0:0] is the sub-list for extension type_name
+	0,  // [0:0] is the sub-list for extension extendee
+	0,  // [0:0] is the sub-list for field type_name
 }
 
 func init() { file_apis_frontend_proto_init() }
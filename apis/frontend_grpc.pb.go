@@ -19,11 +19,14 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	Frontend_Buy_FullMethodName        = "/Frontend/Buy"
-	Frontend_Sell_FullMethodName       = "/Frontend/Sell"
-	Frontend_Cancel_FullMethodName     = "/Frontend/Cancel"
-	Frontend_UpdateBuy_FullMethodName  = "/Frontend/UpdateBuy"
-	Frontend_UpdateSell_FullMethodName = "/Frontend/UpdateSell"
+	Frontend_Buy_FullMethodName                = "/Frontend/Buy"
+	Frontend_Sell_FullMethodName               = "/Frontend/Sell"
+	Frontend_Cancel_FullMethodName             = "/Frontend/Cancel"
+	Frontend_UpdateBuy_FullMethodName          = "/Frontend/UpdateBuy"
+	Frontend_UpdateSell_FullMethodName         = "/Frontend/UpdateSell"
+	Frontend_GetOrder_FullMethodName           = "/Frontend/GetOrder"
+	Frontend_ListOrders_FullMethodName         = "/Frontend/ListOrders"
+	Frontend_StreamOrderUpdates_FullMethodName = "/Frontend/StreamOrderUpdates"
 )
 
 // FrontendClient is the client API for Frontend service.
@@ -35,6 +38,9 @@ type FrontendClient interface {
 	Cancel(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*CancelResponse, error)
 	UpdateBuy(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*UpdateResponse, error)
 	UpdateSell(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*UpdateResponse, error)
+	GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*Order, error)
+	ListOrders(ctx context.Context, in *ListOrdersRequest, opts ...grpc.CallOption) (*ListOrdersResponse, error)
+	StreamOrderUpdates(ctx context.Context, in *StreamOrderUpdatesRequest, opts ...grpc.CallOption) (Frontend_StreamOrderUpdatesClient, error)
 }
 
 type frontendClient struct {
@@ -90,6 +96,56 @@ func (c *frontendClient) UpdateSell(ctx context.Context, in *UpdateRequest, opts
 	return out, nil
 }
 
+func (c *frontendClient) GetOrder(ctx context.Context, in *GetOrderRequest, opts ...grpc.CallOption) (*Order, error) {
+	out := new(Order)
+	err := c.cc.Invoke(ctx, Frontend_GetOrder_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *frontendClient) ListOrders(ctx context.Context, in *ListOrdersRequest, opts ...grpc.CallOption) (*ListOrdersResponse, error) {
+	out := new(ListOrdersResponse)
+	err := c.cc.Invoke(ctx, Frontend_ListOrders_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *frontendClient) StreamOrderUpdates(ctx context.Context, in *StreamOrderUpdatesRequest, opts ...grpc.CallOption) (Frontend_StreamOrderUpdatesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Frontend_ServiceDesc.Streams[0], Frontend_StreamOrderUpdates_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &frontendStreamOrderUpdatesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Frontend_StreamOrderUpdatesClient interface {
+	Recv() (*OrderUpdate, error)
+	grpc.ClientStream
+}
+
+type frontendStreamOrderUpdatesClient struct {
+	grpc.ClientStream
+}
+
+func (x *frontendStreamOrderUpdatesClient) Recv() (*OrderUpdate, error) {
+	m := new(OrderUpdate)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // FrontendServer is the server API for Frontend service.
 // All implementations must embed UnimplementedFrontendServer
 // for forward compatibility
@@ -99,6 +155,9 @@ type FrontendServer interface {
 	Cancel(context.Context, *CancelRequest) (*CancelResponse, error)
 	UpdateBuy(context.Context, *UpdateRequest) (*UpdateResponse, error)
 	UpdateSell(context.Context, *UpdateRequest) (*UpdateResponse, error)
+	GetOrder(context.Context, *GetOrderRequest) (*Order, error)
+	ListOrders(context.Context, *ListOrdersRequest) (*ListOrdersResponse, error)
+	StreamOrderUpdates(*StreamOrderUpdatesRequest, Frontend_StreamOrderUpdatesServer) error
 	mustEmbedUnimplementedFrontendServer()
 }
 
@@ -121,6 +180,15 @@ func (UnimplementedFrontendServer) UpdateBuy(context.Context, *UpdateRequest) (*
 func (UnimplementedFrontendServer) UpdateSell(context.Context, *UpdateRequest) (*UpdateResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateSell not implemented")
 }
+func (UnimplementedFrontendServer) GetOrder(context.Context, *GetOrderRequest) (*Order, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrder not implemented")
+}
+func (UnimplementedFrontendServer) ListOrders(context.Context, *ListOrdersRequest) (*ListOrdersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListOrders not implemented")
+}
+func (UnimplementedFrontendServer) StreamOrderUpdates(*StreamOrderUpdatesRequest, Frontend_StreamOrderUpdatesServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamOrderUpdates not implemented")
+}
 func (UnimplementedFrontendServer) mustEmbedUnimplementedFrontendServer() {}
 
 // UnsafeFrontendServer may be embedded to opt out of forward compatibility for this service.
@@ -224,6 +292,63 @@ func _Frontend_UpdateSell_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Frontend_GetOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FrontendServer).GetOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Frontend_GetOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FrontendServer).GetOrder(ctx, req.(*GetOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Frontend_ListOrders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListOrdersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FrontendServer).ListOrders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Frontend_ListOrders_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FrontendServer).ListOrders(ctx, req.(*ListOrdersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Frontend_StreamOrderUpdates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamOrderUpdatesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FrontendServer).StreamOrderUpdates(m, &frontendStreamOrderUpdatesServer{stream})
+}
+
+type Frontend_StreamOrderUpdatesServer interface {
+	Send(*OrderUpdate) error
+	grpc.ServerStream
+}
+
+type frontendStreamOrderUpdatesServer struct {
+	grpc.ServerStream
+}
+
+func (x *frontendStreamOrderUpdatesServer) Send(m *OrderUpdate) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // Frontend_ServiceDesc is the grpc.ServiceDesc for Frontend service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -251,7 +376,21 @@ var Frontend_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateSell",
 			Handler:    _Frontend_UpdateSell_Handler,
 		},
+		{
+			MethodName: "GetOrder",
+			Handler:    _Frontend_GetOrder_Handler,
+		},
+		{
+			MethodName: "ListOrders",
+			Handler:    _Frontend_ListOrders_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamOrderUpdates",
+			Handler:       _Frontend_StreamOrderUpdates_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "apis/frontend.proto",
 }
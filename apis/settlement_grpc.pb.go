@@ -0,0 +1,146 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.23.4
+// source: apis/settlement.proto
+
+package apis
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Settlement_ListLedgerEntries_FullMethodName = "/Settlement/ListLedgerEntries"
+	Settlement_GetPositions_FullMethodName      = "/Settlement/GetPositions"
+)
+
+// SettlementClient is the client API for Settlement service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SettlementClient interface {
+	ListLedgerEntries(ctx context.Context, in *ListLedgerEntriesRequest, opts ...grpc.CallOption) (*ListLedgerEntriesResponse, error)
+	GetPositions(ctx context.Context, in *GetPositionsRequest, opts ...grpc.CallOption) (*GetPositionsResponse, error)
+}
+
+type settlementClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSettlementClient(cc grpc.ClientConnInterface) SettlementClient {
+	return &settlementClient{cc}
+}
+
+func (c *settlementClient) ListLedgerEntries(ctx context.Context, in *ListLedgerEntriesRequest, opts ...grpc.CallOption) (*ListLedgerEntriesResponse, error) {
+	out := new(ListLedgerEntriesResponse)
+	err := c.cc.Invoke(ctx, Settlement_ListLedgerEntries_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *settlementClient) GetPositions(ctx context.Context, in *GetPositionsRequest, opts ...grpc.CallOption) (*GetPositionsResponse, error) {
+	out := new(GetPositionsResponse)
+	err := c.cc.Invoke(ctx, Settlement_GetPositions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SettlementServer is the server API for Settlement service.
+// All implementations must embed UnimplementedSettlementServer
+// for forward compatibility
+type SettlementServer interface {
+	ListLedgerEntries(context.Context, *ListLedgerEntriesRequest) (*ListLedgerEntriesResponse, error)
+	GetPositions(context.Context, *GetPositionsRequest) (*GetPositionsResponse, error)
+	mustEmbedUnimplementedSettlementServer()
+}
+
+// UnimplementedSettlementServer must be embedded to have forward compatible implementations.
+type UnimplementedSettlementServer struct {
+}
+
+func (UnimplementedSettlementServer) ListLedgerEntries(context.Context, *ListLedgerEntriesRequest) (*ListLedgerEntriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListLedgerEntries not implemented")
+}
+func (UnimplementedSettlementServer) GetPositions(context.Context, *GetPositionsRequest) (*GetPositionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPositions not implemented")
+}
+func (UnimplementedSettlementServer) mustEmbedUnimplementedSettlementServer() {}
+
+// UnsafeSettlementServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SettlementServer will
+// result in compilation errors.
+type UnsafeSettlementServer interface {
+	mustEmbedUnimplementedSettlementServer()
+}
+
+func RegisterSettlementServer(s grpc.ServiceRegistrar, srv SettlementServer) {
+	s.RegisterService(&Settlement_ServiceDesc, srv)
+}
+
+func _Settlement_ListLedgerEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListLedgerEntriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SettlementServer).ListLedgerEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Settlement_ListLedgerEntries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SettlementServer).ListLedgerEntries(ctx, req.(*ListLedgerEntriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Settlement_GetPositions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPositionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SettlementServer).GetPositions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Settlement_GetPositions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SettlementServer).GetPositions(ctx, req.(*GetPositionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Settlement_ServiceDesc is the grpc.ServiceDesc for Settlement service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Settlement_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "Settlement",
+	HandlerType: (*SettlementServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListLedgerEntries",
+			Handler:    _Settlement_ListLedgerEntries_Handler,
+		},
+		{
+			MethodName: "GetPositions",
+			Handler:    _Settlement_GetPositions_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "apis/settlement.proto",
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/atgane/opentd/apis"
+)
+
+// dealHub fans deals out to GetDeal subscribers. A subscriber that cannot keep
+// up has its channel closed instead of silently missing deals.
+type dealHub struct {
+	mu         sync.Mutex
+	next       uint64
+	bufferSize int
+	subs       map[uint64]*dealSubscriber
+}
+
+type dealSubscriber struct {
+	userID string
+	target string
+	ch     chan *apis.GetDealStream
+}
+
+func newDealHub(bufferSize int) *dealHub {
+	if bufferSize <= 0 {
+		bufferSize = 256
+	}
+
+	h := new(dealHub)
+	h.bufferSize = bufferSize
+	h.subs = make(map[uint64]*dealSubscriber)
+	return h
+}
+
+func (h *dealHub) subscribe(userID, target string) (uint64, <-chan *apis.GetDealStream) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.next++
+	s := &dealSubscriber{
+		userID: userID,
+		target: target,
+		ch:     make(chan *apis.GetDealStream, h.bufferSize),
+	}
+	h.subs[h.next] = s
+	return h.next, s.ch
+}
+
+func (h *dealHub) unsubscribe(id uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if s, ok := h.subs[id]; ok {
+		delete(h.subs, id)
+		close(s.ch)
+	}
+}
+
+func (h *dealHub) broadcast(d *apis.GetDealStream) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for id, s := range h.subs {
+		if s.target != d.Target || (s.userID != d.BuyerId && s.userID != d.SellerId) {
+			continue
+		}
+
+		select {
+		case s.ch <- d:
+		default:
+			delete(h.subs, id)
+			close(s.ch)
+		}
+	}
+}
+
+// depthHub fans depth updates out to StreamDepth subscribers of the target,
+// closing the channel of a subscriber that cannot keep up like dealHub.
+type depthHub struct {
+	mu         sync.Mutex
+	next       uint64
+	bufferSize int
+	subs       map[uint64]*depthSubscriber
+}
+
+type depthSubscriber struct {
+	target string
+	ch     chan *apis.DepthUpdate
+}
+
+func newDepthHub(bufferSize int) *depthHub {
+	if bufferSize <= 0 {
+		bufferSize = 256
+	}
+
+	h := new(depthHub)
+	h.bufferSize = bufferSize
+	h.subs = make(map[uint64]*depthSubscriber)
+	return h
+}
+
+func (h *depthHub) subscribe(target string) (uint64, <-chan *apis.DepthUpdate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.next++
+	s := &depthSubscriber{
+		target: target,
+		ch:     make(chan *apis.DepthUpdate, h.bufferSize),
+	}
+	h.subs[h.next] = s
+	return h.next, s.ch
+}
+
+func (h *depthHub) unsubscribe(id uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if s, ok := h.subs[id]; ok {
+		delete(h.subs, id)
+		close(s.ch)
+	}
+}
+
+func (h *depthHub) broadcast(u *apis.DepthUpdate) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for id, s := range h.subs {
+		if s.target != u.Target {
+			continue
+		}
+
+		select {
+		case s.ch <- u:
+		default:
+			delete(h.subs, id)
+			close(s.ch)
+		}
+	}
+	return nil
+}
@@ -2,21 +2,64 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/atgane/opentd/apis"
 	"github.com/atgane/opentd/pkgs/engine"
 	"github.com/atgane/opentd/pkgs/events"
+	"github.com/atgane/opentd/pkgs/journal"
 	"github.com/atgane/opentd/pkgs/logging"
+	"github.com/atgane/opentd/pkgs/orders"
+	"github.com/atgane/opentd/pkgs/snapshot"
 	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type DealerConfig struct {
-	EventConfig      events.EventConfig
-	StreamConfig     events.EventConfig
+	GRPCPort       int
+	DealBufferSize int
+	// DepthBufferSize is how many depth updates a StreamDepth client can lag
+	// behind before it is disconnected.
+	DepthBufferSize int
+	// DedupWindow is how many of the newest journaled event ids are kept to
+	// drop redeliveries. Brokers that commit after the fact, like kafka, can
+	// hand back every event since their last commit after a crash or a
+	// rebalance, not only the last one.
+	DedupWindow int
+	// ExpirySweepInterval is how often the dealer checks for expired GTD and
+	// DAY orders.
+	ExpirySweepInterval time.Duration
+	EventConfig         events.EventConfig
+	// StreamConfig publishes the deal events settlement consumes, so it takes
+	// the same durable transport as the settlement EventConfig.
+	StreamConfig events.EventConfig
+	// OrderUpdateConfig publishes a com.atgane.opentd.OrderUpdate event for
+	// every order state change.
+	OrderUpdateConfig events.EventConfig
+	// MarketDataConfig publishes the L3 feed, a com.atgane.opentd.Stream.L3
+	// event for every change of a resting order. It must not share the order
+	// ingress subject.
+	MarketDataConfig events.EventConfig
+	// DataContentType is the deal event data encoding. Order events are
+	// decoded by their own content type.
+	DataContentType  string
+	EngineConfig     engine.EngineConfig
+	SnapshotConfig   snapshot.SnapshotConfig
+	JournalConfig    journal.JournalConfig
+	OrderStoreConfig orders.OrderStoreConfig
 	RedisConfig      redis.Options
 	LogLevel         string
 	LockExpireSecond time.Duration
@@ -25,13 +68,50 @@ type DealerConfig struct {
 func main() {
 	// TODO: make env loader
 	conf := DealerConfig{
+		GRPCPort: 17012,
 		EventConfig: events.EventConfig{
+			EventType: events.JETSTREAM,
+			JetStreamConfig: events.JetStreamConfig{
+				NATSServer: "localhost:4222",
+				Stream:     "opentd-orders",
+				Subject:    "some-subject",
+				Durable:    "dealer",
+				AckWait:    30 * time.Second,
+			},
+		},
+		StreamConfig: events.EventConfig{
+			EventType: events.JETSTREAM,
+			JetStreamConfig: events.JetStreamConfig{
+				NATSServer: "localhost:4222",
+				Stream:     "opentd-deals",
+				Subject:    "some-deal-subject",
+			},
+		},
+		OrderUpdateConfig: events.EventConfig{
 			EventType: events.NATS,
 			NATSConfig: events.NATSConfig{
 				NATSServer: "localhost:4222",
-				Subject:    "some-subject",
+				Subject:    "some-order-update-subject",
 			},
 		},
+		MarketDataConfig: events.EventConfig{
+			EventType: events.NATS,
+			NATSConfig: events.NATSConfig{
+				NATSServer: "localhost:4222",
+				Subject:    "some-l3-subject",
+			},
+		},
+		ExpirySweepInterval: time.Second,
+		EngineConfig: engine.EngineConfig{
+			SnapshotInterval: 60 * time.Second,
+			HandleKey:        "some-l3-handle-key",
+		},
+		SnapshotConfig: snapshot.SnapshotConfig{
+			StoreType: snapshot.REDIS,
+		},
+		JournalConfig: journal.JournalConfig{
+			JournalType: journal.REDIS,
+		},
 		RedisConfig: redis.Options{
 			Addr: "localhost:6379",
 		},
@@ -41,20 +121,71 @@ func main() {
 
 	logging.SetLevel(conf.LogLevel)
 
+	replayMode := flag.Bool("replay", false, "rebuild order books from the journal, print deals as json lines and exit")
+	replaySnapshot := flag.Bool("replay-snapshot", true, "start the replay from the stored snapshot instead of an empty engine")
+	flag.Parse()
+
+	if *replayMode {
+		if err := replay(context.Background(), conf, *replaySnapshot, os.Stdout); err != nil {
+			log.Fatal().Err(err).Msg("dealer replay error")
+		}
+		return
+	}
+
 	ctx, _ := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 
-	<-ctx.Done()
+	d, err := NewDealer(conf)
+	if err != nil {
+		log.Fatal().Err(err).Msg("dealer initialize error")
+		return
+	}
+
+	go func() {
+		<-ctx.Done()
+		d.Stop()
+	}()
+
+	if err := d.Start(); err != nil {
+		log.Fatal().Err(err).Msg("dealer runtime error")
+	}
 }
 
 type Dealer struct {
 	consumerClient   cloudevents.Client
 	producerClient   cloudevents.Client
+	updateClient     cloudevents.Client
+	marketDataClient cloudevents.Client
 	lockExpireSecond time.Duration
+	dataContentType  string
 	engine           engine.Engine
+	snapshotStore    snapshot.Store
+	journal          journal.Journal
+	orders           *orders.Store
+	recent           *recentEvents
+	receiveMu        sync.Mutex
+	sweepInterval    time.Duration
+	port             int
+	gs               *grpc.Server
+	hub              *dealHub
+	depth            *depthHub
+	statuses         *statusHub
+	quit             chan struct{}
+	done             chan struct{}
+	receivers        sync.WaitGroup
+	stopOnce         sync.Once
+
+	apis.UnimplementedDealerServer
 }
 
 func NewDealer(conf DealerConfig) (*Dealer, error) {
-	// TODO: add matching engine
+	if conf.MarketDataConfig.EventType == conf.EventConfig.EventType &&
+		conf.MarketDataConfig.Destination() == conf.EventConfig.Destination() {
+		return nil, fmt.Errorf("market data must not be published to the order subject %s", conf.EventConfig.Destination())
+	}
+	if err := events.CheckContentType(conf.DataContentType); err != nil {
+		return nil, err
+	}
+
 	ctx := context.Background()
 	consumerClient, err := events.NewConsumerEvent(conf.EventConfig)
 	if err != nil {
@@ -64,47 +195,491 @@ func NewDealer(conf DealerConfig) (*Dealer, error) {
 	if err != nil {
 		return nil, err
 	}
+	updateClient, err := events.NewProducerEvent(conf.OrderUpdateConfig)
+	if err != nil {
+		return nil, err
+	}
+	marketDataClient, err := events.NewProducerEvent(conf.MarketDataConfig)
+	if err != nil {
+		return nil, err
+	}
 
 	redisClient := redis.NewClient(&conf.RedisConfig)
 	if err := redisClient.Ping(ctx).Err(); err != nil {
 		return nil, err
 	}
 
+	matchingEngine, err := engine.NewMatchingEngine(conf.EngineConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotStore, err := snapshot.NewStore(conf.SnapshotConfig, redisClient)
+	if err != nil {
+		return nil, err
+	}
+
+	orderJournal, err := journal.NewJournal(conf.JournalConfig, redisClient)
+	if err != nil {
+		return nil, err
+	}
+
+	orderStore, err := orders.NewStore(conf.OrderStoreConfig, redisClient)
+	if err != nil {
+		return nil, err
+	}
+
+	last, err := recoverEngine(ctx, matchingEngine, snapshotStore, orderJournal, orderStore)
+	if err != nil {
+		return nil, err
+	}
+
+	recent, err := loadRecentEvents(ctx, orderJournal, last, conf.DedupWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	// TODO: TLS certificate branch
+	gs := grpc.NewServer()
 	d := new(Dealer)
 	d.consumerClient = consumerClient
 	d.producerClient = producerClient
+	d.updateClient = updateClient
+	d.marketDataClient = marketDataClient
 	d.lockExpireSecond = conf.LockExpireSecond
+	d.dataContentType = conf.DataContentType
+	d.engine = matchingEngine
+	d.snapshotStore = snapshotStore
+	d.journal = orderJournal
+	d.orders = orderStore
+	d.recent = recent
+	d.sweepInterval = conf.ExpirySweepInterval
+	if d.sweepInterval <= 0 {
+		d.sweepInterval = time.Second
+	}
+	d.port = conf.GRPCPort
+	d.gs = gs
+	d.hub = newDealHub(conf.DealBufferSize)
+	d.depth = newDepthHub(conf.DepthBufferSize)
+	d.statuses = newStatusHub()
+	d.quit = make(chan struct{})
+	d.done = make(chan struct{})
+	apis.RegisterDealerServer(gs, d)
 	return d, nil
 }
 
 func (d *Dealer) Start() error {
-	ctx := context.Background()
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", d.port))
+	if err != nil {
+		return err
+	}
+	return d.Serve(l)
+}
+
+// Serve runs the dealer like Start on a listener of the caller.
+func (d *Dealer) Serve(l net.Listener) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errChan := make(chan error, 3)
+	d.receivers.Add(2)
+	go func() {
+		select {
+		case <-d.quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	go func() {
+		defer d.receivers.Done()
+		d.sweep(ctx)
+	}()
+	go func() {
+		errChan <- d.gs.Serve(l)
+	}()
+	go func() {
+		errChan <- d.engine.Start(d.snapshot, d.stream, d.update, d.depth.broadcast, d.l3)
+	}()
+	go func() {
+		defer d.receivers.Done()
+		for ctx.Err() == nil {
+			if err := d.consumerClient.StartReceiver(ctx, d.receive); err != nil {
+				errChan <- err
+				return
+			}
+		}
+	}()
+
+	// a stop makes the server and the engine return nil before the final
+	// snapshot is taken, so Serve returns once done is closed
+	select {
+	case err := <-errChan:
+		select {
+		case <-d.quit:
+		default:
+			if err != nil {
+				return err
+			}
+		}
+	case <-d.done:
+	}
+	<-d.done
+	return nil
+}
+
+// Stop closes quit to stop the receivers and the streams, and waits for the
+// receivers to return before the final snapshot, so no event is journaled
+// after it or after the journal is closed. done is closed last.
+func (d *Dealer) Stop() {
+	d.stopOnce.Do(func() {
+		close(d.quit)
+		d.receivers.Wait()
+		d.gs.GracefulStop()
+		d.engine.Stop()
+		if err := d.snapshot(); err != nil {
+			log.Error().Err(err).Msg("failed to d.snapshot()")
+		}
+		if err := d.journal.Close(); err != nil {
+			log.Error().Err(err).Msg("failed to d.journal.Close()")
+		}
+		close(d.done)
+	})
+}
+
+func (d *Dealer) GetDeal(req *apis.GetDealRequest, stream apis.Dealer_GetDealServer) error {
+	log.Debug().Interface("req", req).Msg("deal stream accepted")
+
+	if req.UserId == "" || req.Target == "" {
+		return status.Errorf(codes.InvalidArgument, "user_id and target are required")
+	}
+
+	id, ch := d.hub.subscribe(req.UserId, req.Target)
+	defer d.hub.unsubscribe(id)
 
 	for {
-		if err := d.consumerClient.StartReceiver(ctx, d.receive); err != nil {
-			return err
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-d.quit:
+			return nil
+		case deal, ok := <-ch:
+			if !ok {
+				msg := "deal stream fell behind"
+				log.Error().
+					Str("user_id", req.UserId).
+					Str("target", req.Target).
+					Msg(msg)
+				return status.Errorf(codes.ResourceExhausted, msg)
+			}
+			if err := stream.Send(deal); err != nil {
+				log.Error().
+					Err(err).
+					Str("user_id", req.UserId).
+					Str("target", req.Target).
+					Str("deal_id", deal.DealId).
+					Msg("failed to stream.Send()")
+				return err
+			}
 		}
 	}
 }
 
-func (d *Dealer) receive(ctx context.Context, e cloudevents.Event) (err error) {
+func (d *Dealer) GetOrderStatus(req *apis.GetOrderStatusRequest, stream apis.Dealer_GetOrderStatusServer) error {
+	log.Debug().Interface("req", req).Msg("order status accepted")
+
+	if req.UserId == "" || req.RequestId == "" {
+		return status.Errorf(codes.InvalidArgument, "user_id and request_id are required")
+	}
+
+	// the subscription is made before the store is read, so a status saved
+	// in between is not missed
+	ch, cancel := d.statuses.subscribe(req.RequestId)
+	defer cancel()
+
+	s, err := d.orders.GetStatus(stream.Context(), req.RequestId)
+	if errors.Is(err, orders.ErrNotFound) {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-d.quit:
+			return nil
+		case s = <-ch:
+		}
+	} else if err != nil {
+		log.Error().
+			Err(err).
+			Str("user_id", req.UserId).
+			Str("request_id", req.RequestId).
+			Msg("failed to d.orders.GetStatus()")
+		return err
+	}
+
+	// statuses of other users are not disclosed
+	if s.UserId != req.UserId {
+		return status.Errorf(codes.NotFound, "request %s not found", req.RequestId)
+	}
+	if err := stream.Send(s); err != nil {
+		log.Error().
+			Err(err).
+			Str("user_id", req.UserId).
+			Str("request_id", req.RequestId).
+			Msg("failed to stream.Send()")
+		return err
+	}
+	return nil
+}
+
+func (d *Dealer) GetDepth(ctx context.Context, req *apis.GetDepthRequest) (*apis.Depth, error) {
+	log.Debug().Interface("req", req).Msg("depth accepted")
+
+	if req.Target == "" || req.Levels < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "target is required and levels must not be negative")
+	}
+	return d.engine.Depth(req.Target, int(req.Levels)), nil
+}
+
+// StreamDepth sends the whole book of the target and then every depth update
+// after it. The subscription is made before the book is read, so no update is
+// lost in between; updates the book already includes are skipped.
+func (d *Dealer) StreamDepth(req *apis.StreamDepthRequest, stream apis.Dealer_StreamDepthServer) error {
+	log.Debug().Interface("req", req).Msg("depth stream accepted")
+
+	if req.Target == "" {
+		return status.Errorf(codes.InvalidArgument, "target is required")
+	}
+
+	id, ch := d.depth.subscribe(req.Target)
+	defer d.depth.unsubscribe(id)
+
+	book := d.engine.Depth(req.Target, 0)
+	snap := new(apis.DepthUpdate)
+	snap.Target = book.Target
+	snap.Seq = book.Seq
+	snap.Snapshot = true
+	snap.Bids = book.Bids
+	snap.Asks = book.Asks
+	if err := stream.Send(snap); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-d.quit:
+			return nil
+		case u, ok := <-ch:
+			if !ok {
+				msg := "depth stream fell behind"
+				log.Error().Str("target", req.Target).Msg(msg)
+				return status.Errorf(codes.ResourceExhausted, msg)
+			}
+			if u.Seq <= snap.Seq {
+				continue
+			}
+			if err := stream.Send(u); err != nil {
+				log.Error().
+					Err(err).
+					Str("target", req.Target).
+					Uint64("seq", u.Seq).
+					Msg("failed to stream.Send()")
+				return err
+			}
+		}
+	}
+}
+
+func (d *Dealer) receive(ctx context.Context, e cloudevents.Event) error {
 	log.Debug().Interface("event", e).Msg("get event")
 
-	switch e.Type() {
-	case events.BuyType:
-		err = d.engine.AddBuy(e)
-	case events.SellType:
-		err = d.engine.AddSell(e)
-	case events.CancelType:
-		err = d.engine.AddCancel(e)
-	case events.UpdateBuyType:
-		err = d.engine.AddUpdateBuy(e)
-	case events.UpdateSellType:
-		err = d.engine.AddUpdateSell(e)
+	if !events.IsOrderType(e.Type()) {
+		log.Warn().Str("event_id", e.ID()).Str("type", e.Type()).Msg("undefined event type")
+		return nil
 	}
+
+	// the sweeper receives expire events concurrently with the consumer, and
+	// events must be applied in journal order
+	d.receiveMu.Lock()
+	defer d.receiveMu.Unlock()
+
+	// events are acked only after they are applied, so a crash in between
+	// redelivers events that are already journaled
+	if d.recent.contains(e.ID()) {
+		log.Info().Str("event_id", e.ID()).Msg("skip redelivered event")
+		return nil
+	}
+
+	// the journal is written ahead of the engine so an accepted event survives a crash
+	seq, err := d.journal.Append(ctx, e)
 	if err != nil {
+		log.Error().
+			Err(err).
+			Str("event_id", e.ID()).
+			Msg("failed to d.journal.Append()")
 		return err
 	}
+	events.SetSequence(&e, seq)
+	d.recent.add(e.ID())
+
+	// rejections are deterministic and already journaled, redelivering the
+	// event would not change the outcome
+	err = engine.Apply(d.engine, e)
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("event_id", e.ID()).
+			Uint64("seq", seq).
+			Msg("order event rejected")
+	}
+	if s := orderStatus(e, err); s != nil {
+		if err := d.orders.SaveStatus(ctx, s); err != nil {
+			log.Error().
+				Err(err).
+				Str("event_id", e.ID()).
+				Msg("failed to d.orders.SaveStatus()")
+		}
+		d.statuses.publish(s)
+	}
 
 	return nil
 }
+
+// sweep sends an expire event through receive once the earliest resting order
+// has expired. Going through the journal keeps expiry replayable.
+func (d *Dealer) sweep(ctx context.Context) {
+	t := time.NewTicker(d.sweepInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			next, ok := d.engine.NextExpiry()
+			if !ok || next.After(now) {
+				continue
+			}
+
+			e := cloudevents.NewEvent()
+			e.SetID(uuid.New().String())
+			e.SetType(events.ExpireType)
+			e.SetTime(now)
+			e.SetSource(events.DealerSource)
+			if err := d.receive(ctx, e); err != nil {
+				log.Error().Err(err).Str("event_id", e.ID()).Msg("failed to d.receive()")
+			}
+		}
+	}
+}
+
+// stream publishes a deal and then hands it to the GetDeal streams, so a deal
+// the engine retries reaches them once.
+func (d *Dealer) stream(deal *apis.GetDealStream) (cloudevents.Event, error) {
+	e := cloudevents.NewEvent()
+	e.SetID(deal.DealId)
+	e.SetType(events.DealType)
+	if deal.SelfTrade != nil {
+		e.SetType(events.SelfTradeType)
+	}
+	e.SetTime(time.Now())
+	e.SetSource(events.DealerSource)
+	if err := events.SetData(&e, d.dataContentType, deal); err != nil {
+		log.Error().
+			Err(err).
+			Str("deal_id", deal.DealId).
+			Msg("failed to events.SetData()")
+		return e, err
+	}
+
+	if result := d.producerClient.Send(context.Background(), e); cloudevents.IsUndelivered(result) {
+		log.Error().
+			Err(result).
+			Str("deal_id", deal.DealId).
+			Str("target", deal.Target).
+			Msg("failed to d.producerClient.Send()")
+		return e, fmt.Errorf("cloud event message send failed")
+	}
+
+	d.hub.broadcast(deal)
+	return e, nil
+}
+
+// update saves the new state of an order before publishing the change, so a
+// client reacting to the event reads the same state from GetOrder.
+func (d *Dealer) update(u *apis.OrderUpdate) error {
+	ctx := context.Background()
+	if err := d.orders.Save(ctx, u.Order); err != nil {
+		return err
+	}
+
+	e := cloudevents.NewEvent()
+	e.SetID(fmt.Sprintf("%s-%d", u.Order.RequestId, u.Order.Revision))
+	e.SetType(events.OrderUpdateType)
+	e.SetTime(time.Now())
+	e.SetSource(events.DealerSource)
+	events.SetPartitionKey(&e, u.Order.UserId)
+	if err := events.SetData(&e, d.dataContentType, u); err != nil {
+		log.Error().
+			Err(err).
+			Str("request_id", u.Order.RequestId).
+			Msg("failed to events.SetData()")
+		return err
+	}
+
+	if result := d.updateClient.Send(ctx, e); cloudevents.IsUndelivered(result) {
+		log.Error().
+			Err(result).
+			Str("request_id", u.Order.RequestId).
+			Str("kind", u.Kind.String()).
+			Msg("failed to d.updateClient.Send()")
+		return fmt.Errorf("cloud event message send failed")
+	}
+	return nil
+}
+
+func (d *Dealer) l3(msg *apis.L3Message) error {
+	e := cloudevents.NewEvent()
+	e.SetID(fmt.Sprintf("%s-l3-%d", msg.Target, msg.Seq))
+	e.SetType(events.L3Type)
+	e.SetTime(time.Now())
+	e.SetSource(events.DealerSource)
+	events.SetPartitionKey(&e, msg.Target)
+	events.SetSequence(&e, msg.JournalSeq)
+	if err := events.SetData(&e, d.dataContentType, msg); err != nil {
+		log.Error().
+			Err(err).
+			Str("target", msg.Target).
+			Msg("failed to events.SetData()")
+		return err
+	}
+
+	if result := d.marketDataClient.Send(context.Background(), e); cloudevents.IsUndelivered(result) {
+		log.Error().
+			Err(result).
+			Str("target", msg.Target).
+			Uint64("seq", msg.Seq).
+			Msg("failed to d.marketDataClient.Send()")
+		return fmt.Errorf("cloud event message send failed")
+	}
+	return nil
+}
+
+func (d *Dealer) snapshot() error {
+	snap := d.engine.Snapshot()
+	if err := d.snapshotStore.Save(context.Background(), snap); err != nil {
+		return err
+	}
+
+	log.Debug().
+		Uint64("seq", snap.Seq).
+		Str("last_event_id", snap.LastEventID).
+		Msg("snapshot saved")
+
+	// the entries that seed the dedup window on a restart are kept
+	if window := uint64(d.recent.size); snap.JournalSeq > window {
+		if err := d.journal.Trim(context.Background(), snap.JournalSeq-window); err != nil {
+			log.Error().Err(err).Msg("failed to d.journal.Trim()")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/atgane/opentd/apis"
+	"github.com/atgane/opentd/pkgs/events"
+	"github.com/atgane/opentd/pkgs/frontend"
+	"github.com/atgane/opentd/pkgs/journal"
+	"github.com/atgane/opentd/pkgs/logging"
+	"github.com/atgane/opentd/pkgs/servetest"
+	"github.com/atgane/opentd/pkgs/snapshot"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// TestPipeline runs frontend and dealer in one process over the in-memory
+// transport, so the whole order to deal path is covered without docker.
+func TestPipeline(t *testing.T) {
+	logging.SetLevel("error")
+	mr := miniredis.RunT(t)
+
+	orders := events.EventConfig{
+		EventType:      events.INMEMORY,
+		InMemoryConfig: events.InMemoryConfig{Topic: "pipeline-orders"},
+	}
+	deals := events.EventConfig{
+		EventType:      events.INMEMORY,
+		InMemoryConfig: events.InMemoryConfig{Topic: "pipeline-deals"},
+	}
+	updates := events.EventConfig{
+		EventType:      events.INMEMORY,
+		InMemoryConfig: events.InMemoryConfig{Topic: "pipeline-order-updates"},
+	}
+	marketData := events.EventConfig{
+		EventType:      events.INMEMORY,
+		InMemoryConfig: events.InMemoryConfig{Topic: "pipeline-l3"},
+	}
+
+	fconf := frontend.FrontConfig{
+		EventConfig:       orders,
+		OrderUpdateConfig: updates,
+		DataContentType:   events.ApplicationProtobuf,
+		RedisConfig:       redis.Options{Addr: mr.Addr()},
+		LockExpireSecond:  time.Minute,
+	}
+	f, err := frontend.NewFrontend(fconf)
+	require.NoError(t, err)
+	faddr := servetest.Serve(t, f)
+
+	dconf := DealerConfig{
+		EventConfig:       orders,
+		StreamConfig:      deals,
+		OrderUpdateConfig: updates,
+		MarketDataConfig:  marketData,
+		RedisConfig:       redis.Options{Addr: mr.Addr()},
+		SnapshotConfig:    snapshot.SnapshotConfig{StoreType: snapshot.REDIS},
+		JournalConfig:     journal.JournalConfig{JournalType: journal.REDIS},
+	}
+	// the l3 feed is kept off the order subject
+	sameSubject := dconf
+	sameSubject.MarketDataConfig = orders
+	_, err = NewDealer(sameSubject)
+	require.Error(t, err)
+
+	d, err := NewDealer(dconf)
+	require.NoError(t, err)
+	daddr := servetest.Serve(t, d)
+
+	dealConsumer, err := events.NewConsumerEvent(deals)
+	require.NoError(t, err)
+	l3Consumer, err := events.NewConsumerEvent(marketData)
+	require.NoError(t, err)
+	l3 := make(chan cloudevents.Event, 16)
+	published := make(chan cloudevents.Event, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go dealConsumer.StartReceiver(ctx, func(ctx context.Context, e cloudevents.Event) {
+		published <- e
+	})
+	go l3Consumer.StartReceiver(ctx, func(ctx context.Context, e cloudevents.Event) {
+		l3 <- e
+	})
+
+	fconn, err := grpc.DialContext(ctx, faddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer fconn.Close()
+	dconn, err := grpc.DialContext(ctx, daddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer dconn.Close()
+
+	stream, err := apis.NewDealerClient(dconn).GetDeal(ctx, &apis.GetDealRequest{UserId: "buyer", Target: "t"})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		d.hub.mu.Lock()
+		defer d.hub.mu.Unlock()
+		return len(d.hub.subs) == 1
+	}, time.Second, time.Millisecond)
+
+	fc := apis.NewFrontendClient(fconn)
+	updateStream, err := fc.StreamOrderUpdates(ctx, &apis.StreamOrderUpdatesRequest{UserId: "seller"})
+	require.NoError(t, err)
+	// headers are sent once the stream is subscribed
+	_, err = updateStream.Header()
+	require.NoError(t, err)
+	_, err = fc.Sell(ctx, &apis.SellRequest{UserId: "seller", Target: "t", Amount: 2, Price: 10})
+	require.NoError(t, err)
+	_, err = fc.Buy(ctx, &apis.BuyRequest{UserId: "buyer", Target: "t", Amount: 1, Price: 11})
+	require.NoError(t, err)
+
+	deal, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "buyer", deal.BuyerId)
+	require.Equal(t, "seller", deal.SellerId)
+	require.Equal(t, int64(1), deal.Amount)
+	require.Equal(t, int64(10), deal.Price)
+
+	// the resting remainder of the sell is the whole book
+	depth, err := apis.NewDealerClient(dconn).GetDepth(ctx, &apis.GetDepthRequest{Target: "t"})
+	require.NoError(t, err)
+	require.Empty(t, depth.Bids)
+	require.Len(t, depth.Asks, 1)
+	require.Equal(t, int64(10), depth.Asks[0].Price)
+	require.Equal(t, int64(1), depth.Asks[0].Amount)
+
+	depthStream, err := apis.NewDealerClient(dconn).StreamDepth(ctx, &apis.StreamDepthRequest{Target: "t"})
+	require.NoError(t, err)
+	snap, err := depthStream.Recv()
+	require.NoError(t, err)
+	require.True(t, snap.Snapshot)
+	require.Equal(t, depth.Seq, snap.Seq)
+	require.Equal(t, depth.Asks[0].Amount, snap.Asks[0].Amount)
+
+	// every request gets a status, rejections carry their reason
+	res, err := fc.Buy(ctx, &apis.BuyRequest{UserId: "buyer", Target: "t", Amount: 1, Price: 10, PostOnly: true})
+	require.NoError(t, err)
+	st := waitStatus(t, apis.NewDealerClient(dconn), "buyer", res.RequestId)
+	require.Equal(t, apis.OrderState_REJECTED, st.State)
+	require.Equal(t, apis.RejectReason_POST_ONLY_WOULD_CROSS, st.Reason)
+
+	st = waitStatus(t, apis.NewDealerClient(dconn), "buyer", deal.BuyOrderId)
+	require.Equal(t, apis.OrderState_ACCEPTED, st.State)
+	require.Equal(t, "t", st.Target)
+
+	_, err = recvStatus(ctx, apis.NewDealerClient(dconn), "seller", deal.BuyOrderId)
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// the order store follows the engine asynchronously
+	require.Eventually(t, func() bool {
+		o, err := fc.GetOrder(ctx, &apis.GetOrderRequest{UserId: "buyer", RequestId: deal.BuyOrderId})
+		return err == nil && o.Status == apis.OrderLifecycle_ORDER_FILLED
+	}, time.Second, 10*time.Millisecond)
+	_, err = fc.GetOrder(ctx, &apis.GetOrderRequest{UserId: "seller", RequestId: deal.BuyOrderId})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	list, err := fc.ListOrders(ctx, &apis.ListOrdersRequest{UserId: "seller", Target: "t"})
+	require.NoError(t, err)
+	require.Len(t, list.Orders, 1)
+	require.Equal(t, deal.SellOrderId, list.Orders[0].RequestId)
+	require.Equal(t, apis.OrderLifecycle_ORDER_PARTIALLY_FILLED, list.Orders[0].Status)
+	require.Equal(t, int64(1), list.Orders[0].RemainingAmount)
+
+	// the seller saw its order accepted and then partially filled
+	for _, kind := range []apis.OrderUpdateKind{apis.OrderUpdateKind_UPDATE_ACCEPTED, apis.OrderUpdateKind_UPDATE_FILLED} {
+		u, err := updateStream.Recv()
+		require.NoError(t, err)
+		require.Equal(t, kind, u.Kind)
+		require.Equal(t, deal.SellOrderId, u.Order.RequestId)
+	}
+
+	select {
+	case e := <-published:
+		require.Equal(t, events.DealType, e.Type())
+		require.Equal(t, deal.DealId, e.ID())
+	case <-time.After(time.Second):
+		require.FailNow(t, "deal event was not published")
+	}
+
+	// the resting sell was added, then partly executed by the buy
+	for _, action := range []apis.L3Action{apis.L3Action_L3_ADD, apis.L3Action_L3_EXECUTE} {
+		select {
+		case e := <-l3:
+			require.Equal(t, events.L3Type, e.Type())
+			msg := new(apis.L3Message)
+			require.NoError(t, events.DataAs(e, msg))
+			require.Equal(t, action, msg.Action)
+			require.Equal(t, "t", msg.Target)
+		case <-time.After(time.Second):
+			require.FailNow(t, "l3 message was not published")
+		}
+	}
+}
+
+func recvStatus(ctx context.Context, c apis.DealerClient, userID, requestID string) (*apis.OrderStatus, error) {
+	stream, err := c.GetOrderStatus(ctx, &apis.GetOrderStatusRequest{UserId: userID, RequestId: requestID})
+	if err != nil {
+		return nil, err
+	}
+	return stream.Recv()
+}
+
+func waitStatus(t *testing.T, c apis.DealerClient, userID, requestID string) *apis.OrderStatus {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	s, err := recvStatus(ctx, c, userID, requestID)
+	require.NoError(t, err)
+	return s
+}
+
+// TestDealerStop stops a serving dealer from another goroutine, like a signal
+// does, and checks Serve only returns once the shutdown is complete.
+func TestDealerStop(t *testing.T) {
+	logging.SetLevel("error")
+	mr := miniredis.RunT(t)
+
+	topic := func(name string) events.EventConfig {
+		return events.EventConfig{
+			EventType:      events.INMEMORY,
+			InMemoryConfig: events.InMemoryConfig{Topic: "stop-" + name},
+		}
+	}
+	d, err := NewDealer(DealerConfig{
+		EventConfig:       topic("orders"),
+		StreamConfig:      topic("deals"),
+		OrderUpdateConfig: topic("order-updates"),
+		MarketDataConfig:  topic("l3"),
+		RedisConfig:       redis.Options{Addr: mr.Addr()},
+		SnapshotConfig:    snapshot.SnapshotConfig{StoreType: snapshot.REDIS},
+		JournalConfig:     journal.JournalConfig{JournalType: journal.REDIS},
+	})
+	require.NoError(t, err)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	served := make(chan error, 1)
+	go func() {
+		served <- d.Serve(l)
+	}()
+
+	ctx := context.Background()
+	conn, err := grpc.DialContext(ctx, l.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = apis.NewDealerClient(conn).GetDepth(ctx, &apis.GetDepthRequest{Target: "t"})
+	require.NoError(t, err)
+
+	go d.Stop()
+	select {
+	case err := <-served:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "dealer did not stop")
+	}
+	select {
+	case <-d.done:
+	default:
+		require.FailNow(t, "Serve returned before the final snapshot and journal close")
+	}
+}
+
+// TestOrderStatusRestart checks outcomes are answered by a restarted dealer,
+// including one that was lost by a crash right after its event was applied.
+func TestOrderStatusRestart(t *testing.T) {
+	logging.SetLevel("error")
+	mr := miniredis.RunT(t)
+
+	topic := func(name string) events.EventConfig {
+		return events.EventConfig{
+			EventType:      events.INMEMORY,
+			InMemoryConfig: events.InMemoryConfig{Topic: "restart-" + name},
+		}
+	}
+	conf := DealerConfig{
+		EventConfig:       topic("orders"),
+		StreamConfig:      topic("deals"),
+		OrderUpdateConfig: topic("order-updates"),
+		MarketDataConfig:  topic("l3"),
+		RedisConfig:       redis.Options{Addr: mr.Addr()},
+		SnapshotConfig:    snapshot.SnapshotConfig{StoreType: snapshot.REDIS},
+		JournalConfig:     journal.JournalConfig{JournalType: journal.REDIS},
+	}
+	d, err := NewDealer(conf)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for _, id := range []string{"b1", "b2"} {
+		e := cloudevents.NewEvent()
+		e.SetID(id)
+		e.SetType(events.BuyType)
+		e.SetSource(events.FrontendSource)
+		require.NoError(t, e.SetData(cloudevents.ApplicationJSON, &apis.BuyRequest{UserId: "buyer", Target: "t", Amount: 1, Price: 10}))
+		require.NoError(t, d.receive(ctx, e))
+	}
+	mr.Del("opentd:orders:status:b2")
+
+	d, err = NewDealer(conf)
+	require.NoError(t, err)
+	conn, err := grpc.DialContext(ctx, servetest.Serve(t, d), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	c := apis.NewDealerClient(conn)
+	for _, id := range []string{"b1", "b2"} {
+		st := waitStatus(t, c, "buyer", id)
+		require.Equal(t, apis.OrderState_ACCEPTED, st.State)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+// recentEvents remembers the ids of the last journaled events. Brokers deliver
+// at least once, so an event can come back after a crash or a rebalance even
+// though it was already journaled and applied.
+type recentEvents struct {
+	size int
+	ids  []string
+	next int
+	seen map[string]struct{}
+}
+
+func newRecentEvents(size int) *recentEvents {
+	if size <= 0 {
+		size = 10000
+	}
+
+	r := new(recentEvents)
+	r.size = size
+	r.ids = make([]string, 0, size)
+	r.seen = make(map[string]struct{}, size)
+	return r
+}
+
+func (r *recentEvents) contains(id string) bool {
+	_, ok := r.seen[id]
+	return ok
+}
+
+func (r *recentEvents) add(id string) {
+	if r.contains(id) {
+		return
+	}
+
+	if len(r.ids) < r.size {
+		r.ids = append(r.ids, id)
+	} else {
+		delete(r.seen, r.ids[r.next])
+		r.ids[r.next] = id
+		r.next = (r.next + 1) % r.size
+	}
+	r.seen[id] = struct{}{}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/atgane/opentd/pkgs/engine"
+	"github.com/atgane/opentd/pkgs/events"
+	"github.com/atgane/opentd/pkgs/journal"
+	"github.com/atgane/opentd/pkgs/orders"
+	"github.com/atgane/opentd/pkgs/snapshot"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+// recoverEngine restores the latest snapshot, if any, and replays the journal
+// entries written after it. It returns the last journal sequence. Deals
+// produced by the replay stay queued in the engine and are streamed again once
+// it starts; their ids are deterministic so consumers can drop duplicates.
+// Order state changes are handed to the order store again the same way, and
+// the outcomes of the replayed requests are saved again.
+func recoverEngine(ctx context.Context, m *engine.MatchingEngine, store snapshot.Store, j journal.Journal, orderStore *orders.Store) (uint64, error) {
+	var after uint64
+
+	snap, err := store.Load(ctx)
+	switch {
+	case errors.Is(err, snapshot.ErrNotFound):
+		log.Info().Msg("no snapshot found, starting with empty order books")
+	case err != nil:
+		return 0, err
+	default:
+		if err := m.Restore(snap); err != nil {
+			return 0, err
+		}
+		after = snap.JournalSeq
+		log.Info().
+			Uint64("seq", snap.Seq).
+			Uint64("journal_seq", snap.JournalSeq).
+			Str("last_event_id", snap.LastEventID).
+			Int("books", len(snap.Books)).
+			Msg("order books restored from snapshot")
+	}
+
+	// a crash may have come between applying an event and saving its outcome
+	last := after
+	err = j.Read(ctx, after, func(seq uint64, e cloudevents.Event) error {
+		events.SetSequence(&e, seq)
+		last = seq
+		if s := orderStatus(e, engine.Apply(m, e)); s != nil {
+			return orderStore.SaveStatus(ctx, s)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if last > after {
+		log.Info().
+			Uint64("from", after+1).
+			Uint64("to", last).
+			Msg("journal tail replayed")
+	}
+	return last, nil
+}
+
+// loadRecentEvents seeds the dedup window with the newest journal entries.
+func loadRecentEvents(ctx context.Context, j journal.Journal, last uint64, size int) (*recentEvents, error) {
+	r := newRecentEvents(size)
+
+	var after uint64
+	if last > uint64(r.size) {
+		after = last - uint64(r.size)
+	}
+	err := j.Read(ctx, after, func(_ uint64, e cloudevents.Event) error {
+		r.add(e.ID())
+		return nil
+	})
+	return r, err
+}
+
+// replay rebuilds the engine offline and writes every deal it produces to w as
+// one json document per line. Running it twice over the same snapshot and
+// journal yields byte-identical output. A journal trimmed after a snapshot can
+// only be replayed on top of that snapshot.
+func replay(ctx context.Context, conf DealerConfig, useSnapshot bool, w io.Writer) error {
+	redisClient := redis.NewClient(&conf.RedisConfig)
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		return err
+	}
+
+	m, err := engine.NewMatchingEngine(conf.EngineConfig)
+	if err != nil {
+		return err
+	}
+
+	var after uint64
+	if useSnapshot {
+		store, err := snapshot.NewStore(conf.SnapshotConfig, redisClient)
+		if err != nil {
+			return err
+		}
+		snap, err := store.Load(ctx)
+		if err != nil && !errors.Is(err, snapshot.ErrNotFound) {
+			return err
+		}
+		if err == nil {
+			if err := m.Restore(snap); err != nil {
+				return err
+			}
+			after = snap.JournalSeq
+		}
+	}
+
+	j, err := journal.NewJournal(conf.JournalConfig, redisClient)
+	if err != nil {
+		return err
+	}
+	defer j.Close()
+
+	enc := json.NewEncoder(w)
+	flush := func() error {
+		// order states and market data are rebuilt by the live dealer, the
+		// replay only prints deals
+		m.TakeUpdates()
+		m.TakeDepth()
+		m.TakeL3()
+		for _, d := range m.TakeDeals() {
+			if err := enc.Encode(d); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := flush(); err != nil {
+		return err
+	}
+	return j.Read(ctx, after, func(seq uint64, e cloudevents.Event) error {
+		events.SetSequence(&e, seq)
+		_ = engine.Apply(m, e)
+		return flush()
+	})
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/atgane/opentd/apis"
+	"github.com/atgane/opentd/pkgs/engine"
+	"github.com/atgane/opentd/pkgs/events"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"google.golang.org/protobuf/proto"
+)
+
+// statusHub wakes up clients that asked for the outcome of a request before it
+// was applied. Outcomes themselves are kept in the order store.
+type statusHub struct {
+	mu      sync.Mutex
+	waiters map[string][]chan *apis.OrderStatus
+}
+
+func newStatusHub() *statusHub {
+	h := new(statusHub)
+	h.waiters = make(map[string][]chan *apis.OrderStatus)
+	return h
+}
+
+// subscribe returns a channel that receives the status of the request id once
+// it is published. cancel must be called when the caller stops waiting.
+func (h *statusHub) subscribe(id string) (<-chan *apis.OrderStatus, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan *apis.OrderStatus, 1)
+	h.waiters[id] = append(h.waiters[id], ch)
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		waiters := h.waiters[id]
+		for i := range waiters {
+			if waiters[i] == ch {
+				h.waiters[id] = append(waiters[:i], waiters[i+1:]...)
+				break
+			}
+		}
+		if len(h.waiters[id]) == 0 {
+			delete(h.waiters, id)
+		}
+	}
+}
+
+func (h *statusHub) publish(s *apis.OrderStatus) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, ch := range h.waiters[s.RequestId] {
+		ch <- s
+	}
+	delete(h.waiters, s.RequestId)
+}
+
+// orderStatus describes the outcome of applying e. It returns nil for events
+// that no client submitted, like expire events.
+func orderStatus(e cloudevents.Event, err error) *apis.OrderStatus {
+	var req interface {
+		proto.Message
+		GetUserId() string
+		GetTarget() string
+	}
+	switch e.Type() {
+	case events.BuyType:
+		req = new(apis.BuyRequest)
+	case events.SellType:
+		req = new(apis.SellRequest)
+	case events.CancelType:
+		req = new(apis.CancelRequest)
+	case events.UpdateBuyType, events.UpdateSellType:
+		req = new(apis.UpdateRequest)
+	default:
+		return nil
+	}
+
+	s := new(apis.OrderStatus)
+	s.RequestId = e.ID()
+	s.State = apis.OrderState_ACCEPTED
+	if derr := events.DataAs(e, req); derr == nil {
+		s.UserId = req.GetUserId()
+		s.Target = req.GetTarget()
+	} else if err == nil {
+		err = derr
+	}
+
+	if err != nil {
+		s.State = apis.OrderState_REJECTED
+		s.Reason = engine.RejectReason(err)
+		s.Message = err.Error()
+	}
+	return s
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"context"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/atgane/opentd/pkgs/events"
@@ -15,10 +18,28 @@ func main() {
 	conf := frontend.FrontConfig{
 		GRPCPort: 17011,
 		EventConfig: events.EventConfig{
+			EventType: events.JETSTREAM,
+			JetStreamConfig: events.JetStreamConfig{
+				NATSServer: "localhost:4222",
+				Stream:     "opentd-orders",
+				Subject:    "some-subject",
+			},
+		},
+		OrderUpdateConfig: events.EventConfig{
 			EventType: events.NATS,
 			NATSConfig: events.NATSConfig{
 				NATSServer: "localhost:4222",
-				Subject:    "some-subject",
+				Subject:    "some-order-update-subject",
+			},
+		},
+		RiskConfig: frontend.RiskConfig{
+			Enabled: true,
+		},
+		RateLimitConfig: frontend.RateLimitConfig{
+			Enabled: true,
+			Limits: map[string]frontend.RateLimit{
+				"Buy":  {Rate: 10, Burst: 20},
+				"Sell": {Rate: 10, Burst: 20},
 			},
 		},
 		RedisConfig: redis.Options{
@@ -30,12 +51,19 @@ func main() {
 
 	logging.SetLevel(conf.LogLevel)
 
+	ctx, _ := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+
 	fs, err := frontend.NewFrontend(conf)
 	if err != nil {
 		log.Fatal().Err(err).Msg("frontend initialize error")
 		return
 	}
 
+	go func() {
+		<-ctx.Done()
+		fs.Stop()
+	}()
+
 	if err := fs.Start(); err != nil {
 		log.Fatal().Err(err).Msg("frontend runtime error")
 	}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/atgane/opentd/apis"
+	"github.com/atgane/opentd/pkgs/events"
+	"github.com/atgane/opentd/pkgs/ledger"
+	"github.com/atgane/opentd/pkgs/logging"
+	"github.com/atgane/opentd/pkgs/positions"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type SettlementConfig struct {
+	GRPCPort int
+	// EventConfig consumes the deal events the dealer publishes on its
+	// StreamConfig. It must be a durable transport, JetStream, kafka or redis
+	// streams: core NATS and the in-memory transport drop the deals that
+	// arrive while settlement is down or fail to settle.
+	EventConfig     events.EventConfig
+	LedgerConfig    ledger.LedgerConfig
+	PositionsConfig positions.PositionsConfig
+	RedisConfig     redis.Options
+	LogLevel        string
+}
+
+func main() {
+	// TODO: make env loader
+	conf := SettlementConfig{
+		GRPCPort: 17013,
+		EventConfig: events.EventConfig{
+			EventType: events.JETSTREAM,
+			JetStreamConfig: events.JetStreamConfig{
+				NATSServer: "localhost:4222",
+				Stream:     "opentd-deals",
+				Subject:    "some-deal-subject",
+				Durable:    "settlement",
+				AckWait:    30 * time.Second,
+			},
+		},
+		RedisConfig: redis.Options{
+			Addr: "localhost:6379",
+		},
+		LogLevel: "trace",
+	}
+
+	logging.SetLevel(conf.LogLevel)
+
+	ctx, _ := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+
+	s, err := NewSettlement(conf)
+	if err != nil {
+		log.Fatal().Err(err).Msg("settlement initialize error")
+		return
+	}
+
+	go func() {
+		<-ctx.Done()
+		s.Stop()
+	}()
+
+	if err := s.Start(); err != nil {
+		log.Fatal().Err(err).Msg("settlement runtime error")
+	}
+}
+
+type Settlement struct {
+	consumerClient cloudevents.Client
+	ledger         *ledger.Ledger
+	positions      *positions.Tracker
+	port           int
+	gs             *grpc.Server
+	done           chan struct{}
+	stopOnce       sync.Once
+
+	apis.UnimplementedSettlementServer
+}
+
+func NewSettlement(conf SettlementConfig) (*Settlement, error) {
+	ctx := context.Background()
+	consumerClient, err := events.NewConsumerEvent(conf.EventConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	redisClient := redis.NewClient(&conf.RedisConfig)
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		return nil, err
+	}
+
+	l, err := ledger.NewLedger(conf.LedgerConfig, redisClient)
+	if err != nil {
+		return nil, err
+	}
+
+	tracker, err := positions.NewTracker(conf.PositionsConfig, redisClient)
+	if err != nil {
+		return nil, err
+	}
+
+	// TODO: TLS certificate branch
+	gs := grpc.NewServer()
+	s := new(Settlement)
+	s.consumerClient = consumerClient
+	s.ledger = l
+	s.positions = tracker
+	s.port = conf.GRPCPort
+	s.gs = gs
+	s.done = make(chan struct{})
+	apis.RegisterSettlementServer(gs, s)
+	return s, nil
+}
+
+func (s *Settlement) Start() error {
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve runs settlement like Start on a listener of the caller.
+func (s *Settlement) Serve(l net.Listener) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errChan := make(chan error, 2)
+	go func() {
+		errChan <- s.gs.Serve(l)
+	}()
+	go func() {
+		for ctx.Err() == nil {
+			if err := s.consumerClient.StartReceiver(ctx, s.receive); err != nil {
+				errChan <- err
+				return
+			}
+		}
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-s.done:
+		return nil
+	}
+}
+
+func (s *Settlement) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+		s.gs.GracefulStop()
+	})
+}
+
+func (s *Settlement) ListLedgerEntries(ctx context.Context, req *apis.ListLedgerEntriesRequest) (*apis.ListLedgerEntriesResponse, error) {
+	log.Debug().Interface("req", req).Msg("list ledger entries accepted")
+
+	if req.UserId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "user_id is required")
+	}
+
+	res, err := s.ledger.List(ctx, req)
+	if errors.Is(err, ledger.ErrInvalidPageToken) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid page_token")
+	}
+	if err != nil {
+		log.Error().Err(err).Str("user_id", req.UserId).Msg("failed to s.ledger.List()")
+		return nil, status.Errorf(codes.Internal, "failed to list ledger entries")
+	}
+	return res, nil
+}
+
+func (s *Settlement) GetPositions(ctx context.Context, req *apis.GetPositionsRequest) (*apis.GetPositionsResponse, error) {
+	log.Debug().Interface("req", req).Msg("get positions accepted")
+
+	if req.UserId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "user_id is required")
+	}
+
+	list, err := s.positions.Get(ctx, req.UserId)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", req.UserId).Msg("failed to s.positions.Get()")
+		return nil, status.Errorf(codes.Internal, "failed to get positions")
+	}
+	return &apis.GetPositionsResponse{Positions: list}, nil
+}
+
+// receive settles fills and moves the positions of both sides. Either step
+// skips deals it already applied, so a deal that failed halfway is completed
+// when it is redelivered. Cancel reports share the deal type but carry no
+// fill, and self trade records have their own type; both give back the holds
+// of what they cancelled.
+func (s *Settlement) receive(ctx context.Context, e cloudevents.Event) error {
+	log.Debug().Interface("event", e).Msg("get event")
+
+	if e.Type() != events.DealType && e.Type() != events.SelfTradeType {
+		return nil
+	}
+
+	deal := new(apis.GetDealStream)
+	if err := events.DataAs(e, deal); err != nil {
+		log.Error().Err(err).Str("event_id", e.ID()).Msg("failed to events.DataAs()")
+		return nil
+	}
+	if deal.Amount == 0 {
+		if deal.CancelledAmount == 0 && deal.SelfTrade == nil {
+			return nil
+		}
+		if _, err := s.ledger.Release(ctx, deal, e.Time()); err != nil {
+			log.Error().
+				Err(err).
+				Str("deal_id", deal.DealId).
+				Str("target", deal.Target).
+				Msg("failed to s.ledger.Release()")
+			return err
+		}
+		return nil
+	}
+
+	settled, err := s.ledger.Settle(ctx, deal, e.Time())
+	if errors.Is(err, ledger.ErrOverdrawn) {
+		log.Warn().
+			Err(err).
+			Str("deal_id", deal.DealId).
+			Str("buyer_id", deal.BuyerId).
+			Str("seller_id", deal.SellerId).
+			Msg("deal settled on an overdrawn balance")
+		err = nil
+	}
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("deal_id", deal.DealId).
+			Str("target", deal.Target).
+			Msg("failed to s.ledger.Settle()")
+		return err
+	}
+	if !settled {
+		log.Info().Str("deal_id", deal.DealId).Msg("skip settled deal")
+	}
+
+	if _, err := s.positions.Apply(ctx, deal); err != nil {
+		log.Error().
+			Err(err).
+			Str("deal_id", deal.DealId).
+			Str("target", deal.Target).
+			Msg("failed to s.positions.Apply()")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/atgane/opentd/apis"
+	"github.com/atgane/opentd/pkgs/events"
+	"github.com/atgane/opentd/pkgs/logging"
+	"github.com/atgane/opentd/pkgs/servetest"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// TestSettlement feeds deal events to settlement over the in-memory transport
+// and checks the balances, ledgers and positions they leave behind.
+func TestSettlement(t *testing.T) {
+	logging.SetLevel("error")
+	mr := miniredis.RunT(t)
+
+	deals := events.EventConfig{
+		EventType:      events.INMEMORY,
+		InMemoryConfig: events.InMemoryConfig{Topic: "settlement-deals"},
+	}
+	s, err := NewSettlement(SettlementConfig{EventConfig: deals, RedisConfig: redis.Options{Addr: mr.Addr()}})
+	require.NoError(t, err)
+	addr := servetest.Serve(t, s)
+
+	ctx := context.Background()
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	c := apis.NewSettlementClient(conn)
+
+	producer, err := events.NewProducerEvent(deals)
+	require.NoError(t, err)
+	send := func(typ string, d *apis.GetDealStream) {
+		t.Helper()
+		e := cloudevents.NewEvent()
+		e.SetID(d.DealId)
+		e.SetType(typ)
+		e.SetSource(events.DealerSource)
+		e.SetTime(time.UnixMilli(1000))
+		require.NoError(t, events.SetData(&e, cloudevents.ApplicationJSON, d))
+		require.False(t, cloudevents.IsUndelivered(producer.Send(ctx, e)))
+	}
+	settled := func(dealID string) {
+		t.Helper()
+		require.Eventually(t, func() bool {
+			return mr.Exists("opentd:balance:deal:" + dealID)
+		}, time.Second, 10*time.Millisecond)
+	}
+	balance := func(userID, field string) string {
+		return mr.HGet("opentd:balance:"+userID, field)
+	}
+
+	// the frontend held 3 at 12 for the buy and 2 units for the sell
+	mr.HSet("opentd:balance:buyer", "cash", "4", "cash:held", "36")
+	mr.HSet("opentd:balance:hold:b1", "user", "buyer", "asset", "cash", "amount", "36", "unit", "12", "ordered", "3")
+	mr.HSet("opentd:balance:seller", "t", "1", "t:held", "2")
+	mr.HSet("opentd:balance:hold:s1", "user", "seller", "asset", "t", "amount", "2", "unit", "1", "ordered", "2")
+
+	deal := &apis.GetDealStream{DealId: "t-1", Target: "t", Amount: 2, Price: 10, BuyerId: "buyer", SellerId: "seller", BuyOrderId: "b1", SellOrderId: "s1"}
+	send(events.DealType, deal)
+	settled("t-1")
+	require.Equal(t, "8", balance("buyer", "cash"))
+	require.Equal(t, "2", balance("buyer", "t"))
+	require.Equal(t, "20", balance("seller", "cash"))
+
+	// a redelivered deal is posted and counted once
+	send(events.DealType, deal)
+	// the rest of the buy is cancelled and its hold given back
+	send(events.DealType, &apis.GetDealStream{DealId: "t-2", Target: "t", CancelledAmount: 1, BuyerId: "buyer", BuyOrderId: "b1"})
+	settled("t-2")
+	require.Equal(t, "20", balance("buyer", "cash"))
+	require.Equal(t, "0", balance("buyer", "cash:held"))
+	require.False(t, mr.Exists("opentd:balance:hold:b1"))
+
+	positions, err := c.GetPositions(ctx, &apis.GetPositionsRequest{UserId: "buyer"})
+	require.NoError(t, err)
+	require.Len(t, positions.Positions, 1)
+	require.Equal(t, int64(2), positions.Positions[0].Amount)
+
+	entries, err := c.ListLedgerEntries(ctx, &apis.ListLedgerEntriesRequest{UserId: "buyer"})
+	require.NoError(t, err)
+	require.Len(t, entries.Entries, 4)
+	require.Equal(t, "t-2-1", entries.Entries[0].EntryId)
+	require.Equal(t, int64(12), entries.Entries[0].Amount)
+
+	// a self trade record gives back the holds of both orders it cancelled
+	mr.HSet("opentd:balance:user1", "cash:held", "5", "t:held", "1")
+	mr.HSet("opentd:balance:hold:b2", "user", "user1", "asset", "cash", "amount", "5", "unit", "5", "ordered", "1")
+	mr.HSet("opentd:balance:hold:s2", "user", "user1", "asset", "t", "amount", "1", "unit", "1", "ordered", "1")
+	send(events.SelfTradeType, &apis.GetDealStream{
+		DealId:      "t-3",
+		Target:      "t",
+		BuyerId:     "user1",
+		SellerId:    "user1",
+		BuyOrderId:  "b2",
+		SellOrderId: "s2",
+		SelfTrade:   &apis.SelfTrade{Mode: apis.SelfTradePrevention_CANCEL_BOTH, TakerOrderId: "b2", TakerCancelled: 1, MakerOrderId: "s2", MakerCancelled: 1},
+	})
+	settled("t-3")
+	require.Equal(t, "5", balance("user1", "cash"))
+	require.Equal(t, "1", balance("user1", "t"))
+
+	// a matched deal is settled even when neither side can pay for it
+	send(events.DealType, &apis.GetDealStream{DealId: "t-4", Target: "t", Amount: 1, Price: 10, BuyerId: "user2", SellerId: "user3"})
+	settled("t-4")
+	require.Equal(t, "-10", balance("user2", "cash"))
+	require.Equal(t, "-1", balance("user3", "t"))
+	members, err := mr.Members("opentd:balance:overdrawn")
+	require.NoError(t, err)
+	require.Equal(t, []string{"user2", "user3"}, members)
+	require.Eventually(t, func() bool {
+		positions, err := c.GetPositions(ctx, &apis.GetPositionsRequest{UserId: "user3"})
+		return err == nil && len(positions.Positions) == 1 && positions.Positions[0].Amount == -1
+	}, time.Second, 10*time.Millisecond)
+
+	positions, err = c.GetPositions(ctx, &apis.GetPositionsRequest{UserId: "buyer"})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), positions.Positions[0].Amount)
+
+	_, err = c.ListLedgerEntries(ctx, &apis.ListLedgerEntriesRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
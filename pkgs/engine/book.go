@@ -0,0 +1,415 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/atgane/opentd/apis"
+)
+
+type priceLevel struct {
+	price  int64
+	orders []*Order
+}
+
+// orderBook keeps one target's resting orders. bids are sorted by descending
+// price and asks by ascending price, so the best level is always index 0 and
+// orders inside a level are kept in arrival order.
+type orderBook struct {
+	target    string
+	bids      []*priceLevel
+	asks      []*priceLevel
+	dealSeq   uint64
+	lastPrice int64
+	stops     stopBook
+	// positions is the net bought amount of every user that traded the
+	// target, used by reduce only orders. It is derived from the deals of the
+	// book alone, so it replays with the journal; settlement keeps the
+	// positions users see.
+	positions map[string]int64
+	fees      FeeSchedule
+	// volumes is the lifetime traded value of every user in the target, only
+	// counted while the schedule has tiers, see FeeSchedule.Tiers.
+	volumes  map[string]int64
+	depthSeq uint64
+	dirty    map[depthKey]struct{}
+	feedSeq  uint64
+	l3       []*apis.L3Message
+	// handleKey keys the L3 handles of the book's orders, see handle.
+	handleKey []byte
+}
+
+func newOrderBook(target string, fees FeeSchedule, handleKey []byte) *orderBook {
+	b := new(orderBook)
+	b.target = target
+	b.handleKey = handleKey
+	b.positions = make(map[string]int64)
+	b.fees = fees
+	b.volumes = make(map[string]int64)
+	return b
+}
+
+func (b *orderBook) side(s Side) *[]*priceLevel {
+	if s == Buy {
+		return &b.bids
+	}
+	return &b.asks
+}
+
+func (b *orderBook) opposite(s Side) *[]*priceLevel {
+	if s == Buy {
+		return &b.asks
+	}
+	return &b.bids
+}
+
+// crosses reports whether an order of side s at price can trade against level.
+func crosses(s Side, price int64, level *priceLevel) bool {
+	if s == Buy {
+		return level.price <= price
+	}
+	return level.price >= price
+}
+
+// better reports whether price a has priority over price b on side s.
+func better(s Side, a, b int64) bool {
+	if s == Buy {
+		return a > b
+	}
+	return a < b
+}
+
+// change is an order touched by match with its amounts from before.
+type change struct {
+	o         *Order
+	filled    int64
+	cancelled int64
+}
+
+func changeOf(o *Order) change {
+	return change{o: o, filled: o.Filled, cancelled: o.Cancelled}
+}
+
+func (c change) changed() bool {
+	return c.o.Filled > c.filled || c.o.Cancelled > c.cancelled
+}
+
+// kind tells whether the change ended the order by cancelling it or filled it.
+func (c change) kind() apis.OrderUpdateKind {
+	if c.o.Cancelled > c.cancelled {
+		return apis.OrderUpdateKind_UPDATE_CANCELLED
+	}
+	return apis.OrderUpdateKind_UPDATE_FILLED
+}
+
+// match fills o against the opposite side while prices cross and returns the
+// deals in execution order together with every maker it changed. Makers only
+// trade their shown amount at a time. Fully filled makers, and makers cancelled
+// by self trade prevention, are removed from the book; o.Amount is left with
+// the unfilled remainder.
+func (b *orderBook) match(o *Order) ([]*apis.GetDealStream, []change) {
+	var deals []*apis.GetDealStream
+	var changes []change
+	seen := make(map[*Order]struct{})
+	touch := func(maker *Order) {
+		if _, ok := seen[maker]; !ok {
+			seen[maker] = struct{}{}
+			changes = append(changes, changeOf(maker))
+		}
+	}
+
+	levels := b.opposite(o.Side)
+	for o.Amount > 0 && len(*levels) > 0 {
+		level := (*levels)[0]
+		if !crosses(o.Side, o.Price, level) {
+			break
+		}
+		b.mark(level.orders[0].Side, level.price)
+
+		for o.Amount > 0 && len(level.orders) > 0 {
+			maker := level.orders[0]
+			touch(maker)
+			if maker.UserID == o.UserID && o.SelfTrade.prevents() {
+				d := b.preventSelfTrade(o, maker, level.price)
+				deals = append(deals, d)
+				if d.SelfTrade.MakerCancelled > 0 {
+					action := apis.L3Action_L3_MODIFY
+					if maker.Amount == 0 {
+						action = apis.L3Action_L3_DELETE
+					}
+					b.feed(action, maker, 0, "")
+				}
+				if maker.Amount == 0 {
+					level.orders = level.orders[1:]
+				}
+				continue
+			}
+
+			amount := min(o.Amount, maker.shown())
+			o.Amount -= amount
+			o.Filled += amount
+			maker.Amount -= amount
+			maker.Filled += amount
+			if maker.DisplayAmount > 0 {
+				maker.Visible -= amount
+			}
+			d := b.newDeal(o, maker, amount, level.price)
+			deals = append(deals, d)
+			b.feed(apis.L3Action_L3_EXECUTE, maker, amount, d.DealId)
+
+			if maker.Amount == 0 {
+				level.orders = level.orders[1:]
+			} else if maker.Visible == 0 && maker.DisplayAmount > 0 {
+				// a refilled iceberg slice loses its time priority
+				b.feed(apis.L3Action_L3_DELETE, maker, 0, "")
+				maker.reveal()
+				level.orders = append(level.orders[1:], maker)
+				b.feed(apis.L3Action_L3_ADD, maker, 0, "")
+			}
+		}
+
+		if len(level.orders) == 0 {
+			*levels = (*levels)[1:]
+		}
+	}
+
+	return deals, changes
+}
+
+// wouldCross reports whether o would trade on entry.
+func (b *orderBook) wouldCross(o *Order) bool {
+	levels := *b.opposite(o.Side)
+	return len(levels) > 0 && crosses(o.Side, o.Price, levels[0])
+}
+
+// postOnlyPrice returns the most aggressive price up to the price of o at
+// which o rests without trading, or 0 when there is none.
+func (b *orderBook) postOnlyPrice(o *Order) int64 {
+	levels := *b.opposite(o.Side)
+	if len(levels) == 0 {
+		return o.Price
+	}
+	if o.Side == Buy {
+		return min(o.Price, levels[0].price-1)
+	}
+	return max(o.Price, levels[0].price+1)
+}
+
+// reduces reports whether o, at amount, only reduces the position of its
+// user. The other open reduce only orders of the user on the same side count
+// against the position too, since they would reduce it first.
+func (b *orderBook) reduces(o *Order, amount int64) bool {
+	pos := b.positions[o.UserID]
+	if o.Side == Buy {
+		pos = -pos
+	}
+
+	open := amount
+	count := func(other *Order) {
+		if other != o && other.ReduceOnly && other.UserID == o.UserID {
+			open += other.Amount
+		}
+	}
+	for _, level := range *b.side(o.Side) {
+		for _, other := range level.orders {
+			count(other)
+		}
+	}
+	for _, other := range *b.stops.side(o.Side) {
+		count(other)
+	}
+	return pos > 0 && open <= pos
+}
+
+// fillable reports whether o can be filled completely right now.
+func (b *orderBook) fillable(o *Order) bool {
+	var amount int64
+	for _, level := range *b.opposite(o.Side) {
+		if !crosses(o.Side, o.Price, level) {
+			return false
+		}
+		for _, maker := range level.orders {
+			if maker.UserID == o.UserID && o.SelfTrade.prevents() {
+				if o.SelfTrade.cancelsTaker() {
+					return false
+				}
+				continue
+			}
+			amount += maker.Amount
+			if amount >= o.Amount {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (b *orderBook) newDeal(taker, maker *Order, amount, price int64) *apis.GetDealStream {
+	b.dealSeq++
+
+	d := new(apis.GetDealStream)
+	d.DealId = fmt.Sprintf("%s-%d", b.target, b.dealSeq)
+	d.Target = b.target
+	d.Amount = amount
+	d.Price = price
+	if taker.Side == Buy {
+		d.BuyerId, d.SellerId = taker.UserID, maker.UserID
+		d.BuyOrderId, d.SellOrderId = taker.ID, maker.ID
+	} else {
+		d.BuyerId, d.SellerId = maker.UserID, taker.UserID
+		d.BuyOrderId, d.SellOrderId = maker.ID, taker.ID
+	}
+	b.positions[d.BuyerId] += amount
+	b.positions[d.SellerId] -= amount
+	for _, user := range []string{d.BuyerId, d.SellerId} {
+		if b.positions[user] == 0 {
+			delete(b.positions, user)
+		}
+	}
+	b.charge(d, taker, maker)
+	return d
+}
+
+// preventSelfTrade applies the self trade mode of taker to a match with maker
+// of the same user and returns the record explaining what was cancelled.
+func (b *orderBook) preventSelfTrade(taker, maker *Order, price int64) *apis.GetDealStream {
+	st := new(apis.SelfTrade)
+	st.Mode = apis.SelfTradePrevention(taker.SelfTrade)
+	st.TakerOrderId = taker.ID
+	st.MakerOrderId = maker.ID
+
+	switch taker.SelfTrade {
+	case CancelNewest:
+		st.TakerCancelled = taker.Amount
+	case CancelOldest:
+		st.MakerCancelled = maker.Amount
+	case CancelBoth:
+		st.TakerCancelled = taker.Amount
+		st.MakerCancelled = maker.Amount
+	case DecrementAndCancel:
+		amount := min(taker.Amount, maker.Amount)
+		st.TakerCancelled = amount
+		st.MakerCancelled = amount
+	}
+
+	taker.Amount -= st.TakerCancelled
+	taker.Cancelled += st.TakerCancelled
+	maker.Amount -= st.MakerCancelled
+	maker.Cancelled += st.MakerCancelled
+	if maker.DisplayAmount > 0 {
+		// the maker keeps its place, only its slice shrinks or is refilled
+		maker.Visible = min(maker.Visible, maker.Amount)
+		if maker.Visible == 0 {
+			maker.reveal()
+		}
+	}
+
+	b.dealSeq++
+	d := new(apis.GetDealStream)
+	d.DealId = fmt.Sprintf("%s-%d", b.target, b.dealSeq)
+	d.Target = b.target
+	d.Price = price
+	d.BuyerId, d.SellerId = taker.UserID, maker.UserID
+	d.BuyOrderId, d.SellOrderId = taker.ID, maker.ID
+	if taker.Side == Sell {
+		d.BuyOrderId, d.SellOrderId = maker.ID, taker.ID
+	}
+	d.SelfTrade = st
+	return d
+}
+
+// newCancelReport cancels the remaining amount of o and tells its submitter.
+// It takes a deal id so it is ordered with the deals of the book.
+func (b *orderBook) newCancelReport(o *Order) *apis.GetDealStream {
+	b.dealSeq++
+
+	d := new(apis.GetDealStream)
+	d.DealId = fmt.Sprintf("%s-%d", b.target, b.dealSeq)
+	d.Target = b.target
+	d.CancelledAmount = o.Amount
+	o.Cancelled += o.Amount
+	o.Amount = 0
+	if o.Side == Buy {
+		d.BuyerId, d.BuyOrderId = o.UserID, o.ID
+	} else {
+		d.SellerId, d.SellOrderId = o.UserID, o.ID
+	}
+	return d
+}
+
+// marketLimit returns the worst price a market order of side s may trade at.
+// protection is an absolute bound and slippage is relative to the best
+// opposite price; the tighter one wins and 0 disables either.
+func (b *orderBook) marketLimit(s Side, protection, slippage int64) int64 {
+	limit := int64(math.MaxInt64)
+	if s == Sell {
+		limit = 1
+	}
+	if protection > 0 {
+		limit = protection
+	}
+
+	levels := *b.opposite(s)
+	if slippage <= 0 || len(levels) == 0 {
+		return limit
+	}
+
+	best := levels[0].price
+	if s == Buy {
+		if best > math.MaxInt64-slippage {
+			return limit
+		}
+		return min(limit, best+slippage)
+	}
+	return max(limit, best-slippage)
+}
+
+// rest appends o to the back of its price level, creating the level if needed.
+func (b *orderBook) rest(o *Order) {
+	b.mark(o.Side, o.Price)
+	if o.Handle == 0 {
+		o.Handle = b.handle(o.ID)
+	}
+	b.feed(apis.L3Action_L3_ADD, o, 0, "")
+	levels := b.side(o.Side)
+	idx := sort.Search(len(*levels), func(i int) bool {
+		return !better(o.Side, (*levels)[i].price, o.Price)
+	})
+
+	if idx < len(*levels) && (*levels)[idx].price == o.Price {
+		(*levels)[idx].orders = append((*levels)[idx].orders, o)
+		return
+	}
+
+	level := &priceLevel{price: o.Price, orders: []*Order{o}}
+	*levels = append(*levels, nil)
+	copy((*levels)[idx+1:], (*levels)[idx:])
+	(*levels)[idx] = level
+}
+
+// remove takes o out of its price level and drops the level once it is empty.
+func (b *orderBook) remove(o *Order) bool {
+	levels := b.side(o.Side)
+	idx := sort.Search(len(*levels), func(i int) bool {
+		return !better(o.Side, (*levels)[i].price, o.Price)
+	})
+	if idx >= len(*levels) || (*levels)[idx].price != o.Price {
+		return false
+	}
+
+	level := (*levels)[idx]
+	for i := range level.orders {
+		if level.orders[i] != o {
+			continue
+		}
+		level.orders = append(level.orders[:i], level.orders[i+1:]...)
+		b.mark(o.Side, o.Price)
+		b.feed(apis.L3Action_L3_DELETE, o, 0, "")
+		if len(level.orders) == 0 {
+			*levels = append((*levels)[:idx], (*levels)[idx+1:]...)
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"sort"
+
+	"github.com/atgane/opentd/apis"
+)
+
+type depthKey struct {
+	side  Side
+	price int64
+}
+
+// mark records that the level of side at price changed during the current
+// event.
+func (b *orderBook) mark(side Side, price int64) {
+	if b.dirty == nil {
+		b.dirty = make(map[depthKey]struct{})
+	}
+	b.dirty[depthKey{side: side, price: price}] = struct{}{}
+}
+
+// level returns the level of side at price, or nil.
+func (b *orderBook) level(side Side, price int64) *priceLevel {
+	levels := *b.side(side)
+	idx := sort.Search(len(levels), func(i int) bool {
+		return !better(side, levels[i].price, price)
+	})
+	if idx < len(levels) && levels[idx].price == price {
+		return levels[idx]
+	}
+	return nil
+}
+
+func depthLevel(level *priceLevel) *apis.PriceLevel {
+	l := new(apis.PriceLevel)
+	l.Price = level.price
+	for _, o := range level.orders {
+		l.Amount += o.shown()
+	}
+	l.OrderCount = int64(len(level.orders))
+	return l
+}
+
+// depthUpdate returns the changed levels of b since the last call, best price
+// first, or nil when nothing visible changed.
+func (b *orderBook) depthUpdate() *apis.DepthUpdate {
+	if len(b.dirty) == 0 {
+		return nil
+	}
+
+	keys := make([]depthKey, 0, len(b.dirty))
+	for k := range b.dirty {
+		keys = append(keys, k)
+	}
+	b.dirty = nil
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].side != keys[j].side {
+			return keys[i].side < keys[j].side
+		}
+		return better(keys[i].side, keys[i].price, keys[j].price)
+	})
+
+	b.depthSeq++
+	u := new(apis.DepthUpdate)
+	u.Target = b.target
+	u.Seq = b.depthSeq
+	for _, k := range keys {
+		l := &apis.PriceLevel{Price: k.price}
+		if level := b.level(k.side, k.price); level != nil {
+			l = depthLevel(level)
+		}
+		if k.side == Buy {
+			u.Bids = append(u.Bids, l)
+		} else {
+			u.Asks = append(u.Asks, l)
+		}
+	}
+	return u
+}
+
+// flushMarketData queues the depth updates and L3 messages of every book the
+// current event changed. It runs once per event so clients never see half
+// applied events. m.mu must be held.
+func (m *MatchingEngine) flushMarketData() {
+	targets := make([]string, 0, len(m.books))
+	for target, b := range m.books {
+		if len(b.dirty) > 0 || len(b.l3) > 0 {
+			targets = append(targets, target)
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+	sort.Strings(targets)
+
+	for _, target := range targets {
+		b := m.books[target]
+		if u := b.depthUpdate(); u != nil {
+			m.depth = append(m.depth, u)
+		}
+		for _, msg := range b.l3 {
+			msg.JournalSeq = m.journalSeq
+			msg.Timestamp = m.clock
+		}
+		m.l3 = append(m.l3, b.l3...)
+		b.l3 = nil
+	}
+	m.wake()
+}
+
+// Depth returns up to levels price levels of each side of target, every level
+// when levels is 0, along with the sequence of the last depth update it
+// includes.
+func (m *MatchingEngine) Depth(target string, levels int) *apis.Depth {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d := new(apis.Depth)
+	d.Target = target
+	b, ok := m.books[target]
+	if !ok {
+		return d
+	}
+
+	d.Seq = b.depthSeq
+	for side, list := range map[Side]*[]*apis.PriceLevel{Buy: &d.Bids, Sell: &d.Asks} {
+		for i, level := range *b.side(side) {
+			if levels > 0 && i == levels {
+				break
+			}
+			*list = append(*list, depthLevel(level))
+		}
+	}
+	return d
+}
+
+// TakeDepth returns and clears the depth updates that have not been handed to
+// Start's depth callback yet, like TakeDeals.
+func (m *MatchingEngine) TakeDepth() []*apis.DepthUpdate {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	depth := m.depth
+	m.depth = nil
+	return depth
+}
@@ -1,7 +1,11 @@
 package engine
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/atgane/opentd/apis"
+	"github.com/atgane/opentd/pkgs/events"
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 )
 
@@ -11,5 +15,33 @@ type Engine interface {
 	AddCancel(e cloudevents.Event) error
 	AddUpdateBuy(e cloudevents.Event) error
 	AddUpdateSell(e cloudevents.Event) error
-	Start(snapshot func() error, stream func(apis.GetDealStream) (cloudevents.Event, error)) error
+	AddExpire(e cloudevents.Event) error
+	// NextExpiry reports when the earliest resting order expires, so the
+	// caller knows when to send an expire event.
+	NextExpiry() (time.Time, bool)
+	Start(snapshot func() error, stream func(*apis.GetDealStream) (cloudevents.Event, error), update func(*apis.OrderUpdate) error, depth func(*apis.DepthUpdate) error, l3 func(*apis.L3Message) error) error
+	Depth(target string, levels int) *apis.Depth
+	Stop()
+	Snapshot() *Snapshot
+	Restore(s *Snapshot) error
+}
+
+// Apply dispatches an order event to the matching Add method of en.
+func Apply(en Engine, e cloudevents.Event) error {
+	switch e.Type() {
+	case events.BuyType:
+		return en.AddBuy(e)
+	case events.SellType:
+		return en.AddSell(e)
+	case events.CancelType:
+		return en.AddCancel(e)
+	case events.UpdateBuyType:
+		return en.AddUpdateBuy(e)
+	case events.UpdateSellType:
+		return en.AddUpdateSell(e)
+	case events.ExpireType:
+		return en.AddExpire(e)
+	}
+
+	return fmt.Errorf("undefined event type %s", e.Type())
 }
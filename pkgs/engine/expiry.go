@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"container/heap"
+	"time"
+
+	"github.com/atgane/opentd/apis"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// expiryQueue is a min heap of resting GTD and DAY orders by expiry. Entries
+// are not removed when an order leaves the book; expire skips them instead.
+type expiryQueue []expiryEntry
+
+// expiryEntry copies the heap keys, since o.Seq changes when an order is
+// placed again by an update.
+type expiryEntry struct {
+	at  int64
+	seq uint64
+	o   *Order
+}
+
+func (q expiryQueue) Len() int { return len(q) }
+
+func (q expiryQueue) Less(i, j int) bool {
+	if q[i].at != q[j].at {
+		return q[i].at < q[j].at
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q expiryQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *expiryQueue) Push(x interface{}) { *q = append(*q, x.(expiryEntry)) }
+
+func (q *expiryQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+// AddExpire only advances the engine clock to the event time, which cancels
+// every order that expired by then. The dealer sends these events through the
+// journal so expiry replays exactly like the orders around it.
+func (m *MatchingEngine) AddExpire(e cloudevents.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer m.flushMarketData()
+	m.track(e)
+	return nil
+}
+
+// NextExpiry returns the earliest expiry among resting orders.
+func (m *MatchingEngine) NextExpiry() (time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.expiries) == 0 {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(m.expiries[0].at), true
+}
+
+// advance moves the engine clock forward to e's time and expires orders. Event
+// times are only trusted to move forward, so the clock, like everything else,
+// depends on the journal order alone. m.mu must be held.
+func (m *MatchingEngine) advance(e cloudevents.Event) {
+	if t := e.Time(); !t.IsZero() && t.UnixMilli() > m.clock {
+		m.clock = t.UnixMilli()
+	}
+
+	var reports []*apis.GetDealStream
+	for len(m.expiries) > 0 && m.expiries[0].at <= m.clock {
+		entry := heap.Pop(&m.expiries).(expiryEntry)
+		o := entry.o
+		if m.orders[o.ID] != o || o.Seq != entry.seq {
+			continue
+		}
+
+		m.unlink(o)
+		reports = append(reports, m.books[o.Target].newCancelReport(o))
+		m.report(apis.OrderUpdateKind_UPDATE_EXPIRED, o)
+	}
+	m.publish(reports)
+}
+
+// dayEnd returns the first DayEnd cutoff after the engine clock.
+func (m *MatchingEngine) dayEnd() int64 {
+	now := time.UnixMilli(m.clock).UTC()
+	end := now.Truncate(24 * time.Hour).Add(m.dayEndOffset)
+	for !end.After(now) {
+		end = end.Add(24 * time.Hour)
+	}
+	return end.UnixMilli()
+}
@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/atgane/opentd/apis"
+)
+
+const maxFeeBps = 10000
+
+// FeeConfig sets the maker and taker fees of every deal. Fees are charged in
+// cash on the value of the deal, in basis points, rounded up.
+type FeeConfig struct {
+	// Default applies to targets without a schedule of their own.
+	Default FeeSchedule
+	Targets map[string]FeeSchedule
+}
+
+type FeeSchedule struct {
+	MakerBps int64
+	TakerBps int64
+	// Tiers replace the rates above for users whose traded value in the
+	// target reaches their Volume, the highest tier reached wins. Volumes
+	// have no period: they count every deal of the user in this target since
+	// it first had tiers and never reset. They are kept in the engine and its
+	// snapshots only, settlement does not see them.
+	Tiers []FeeTier
+}
+
+type FeeTier struct {
+	// Volume is the lifetime traded value, in cash, of a user in the target
+	// from which the tier applies.
+	Volume   int64
+	MakerBps int64
+	TakerBps int64
+}
+
+func (conf FeeConfig) validate() error {
+	if err := conf.Default.validate(); err != nil {
+		return fmt.Errorf("default fee schedule: %w", err)
+	}
+	for target, s := range conf.Targets {
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("fee schedule of %s: %w", target, err)
+		}
+	}
+	return nil
+}
+
+func (s FeeSchedule) validate() error {
+	if !validBps(s.MakerBps) || !validBps(s.TakerBps) {
+		return fmt.Errorf("fees must be within 0 and %d bps", maxFeeBps)
+	}
+	for i, tier := range s.Tiers {
+		if !validBps(tier.MakerBps) || !validBps(tier.TakerBps) {
+			return fmt.Errorf("fees must be within 0 and %d bps", maxFeeBps)
+		}
+		if tier.Volume <= 0 || (i > 0 && tier.Volume <= s.Tiers[i-1].Volume) {
+			return fmt.Errorf("tier volumes must be positive and ascending")
+		}
+	}
+	return nil
+}
+
+func validBps(bps int64) bool {
+	return bps >= 0 && bps <= maxFeeBps
+}
+
+// schedule returns the fee schedule of target.
+func (conf FeeConfig) schedule(target string) FeeSchedule {
+	if s, ok := conf.Targets[target]; ok {
+		return s
+	}
+	return conf.Default
+}
+
+// rates returns the maker and taker rates of a user that traded volume.
+func (s FeeSchedule) rates(volume int64) (int64, int64) {
+	idx := sort.Search(len(s.Tiers), func(i int) bool {
+		return s.Tiers[i].Volume > volume
+	})
+	if idx == 0 {
+		return s.MakerBps, s.TakerBps
+	}
+	return s.Tiers[idx-1].MakerBps, s.Tiers[idx-1].TakerBps
+}
+
+// fee returns bps of value rounded up.
+func fee(value, bps int64) int64 {
+	return value/maxFeeBps*bps + (value%maxFeeBps*bps+maxFeeBps-1)/maxFeeBps
+}
+
+// charge sets the fees of d, traded by taker against maker, and counts its
+// value towards the tiers of both users.
+func (b *orderBook) charge(d *apis.GetDealStream, taker, maker *Order) {
+	value := d.Price * d.Amount
+	if d.Price > 0 && d.Amount > math.MaxInt64/d.Price {
+		value = math.MaxInt64
+	}
+
+	makerBps, _ := b.fees.rates(b.volumes[maker.UserID])
+	_, takerBps := b.fees.rates(b.volumes[taker.UserID])
+	makerFee, takerFee := fee(value, makerBps), fee(value, takerBps)
+	if taker.Side == Buy {
+		d.BuyerFee, d.SellerFee = takerFee, makerFee
+	} else {
+		d.BuyerFee, d.SellerFee = makerFee, takerFee
+	}
+
+	if len(b.fees.Tiers) == 0 {
+		return
+	}
+	for _, user := range []string{d.BuyerId, d.SellerId} {
+		if b.volumes[user] > math.MaxInt64-value {
+			b.volumes[user] = math.MaxInt64
+		} else {
+			b.volumes[user] += value
+		}
+		if d.BuyerId == d.SellerId {
+			break
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/atgane/opentd/apis"
+)
+
+// feed records an L3 message about the resting order o. executed and dealID
+// are only set for executions.
+func (b *orderBook) feed(action apis.L3Action, o *Order, executed int64, dealID string) {
+	b.feedSeq++
+
+	msg := new(apis.L3Message)
+	msg.Target = b.target
+	msg.Seq = b.feedSeq
+	msg.Action = action
+	msg.OrderHandle = o.Handle
+	msg.Side = apis.Side(o.Side)
+	msg.Price = o.Price
+	if action != apis.L3Action_L3_DELETE {
+		msg.Amount = o.shown()
+	}
+	msg.ExecutedAmount = executed
+	msg.DealId = dealID
+	b.l3 = append(b.l3, msg)
+}
+
+// handle derives the L3 handle of the order id. It is keyed so that handles
+// can neither be told from order ids nor be ordered by arrival.
+func (b *orderBook) handle(id string) uint64 {
+	mac := hmac.New(sha256.New, b.handleKey)
+	mac.Write([]byte(id))
+	h := binary.BigEndian.Uint64(mac.Sum(nil))
+	if h == 0 {
+		h = 1
+	}
+	return h
+}
+
+// TakeL3 returns and clears the L3 messages that have not been handed to
+// Start's l3 callback yet, like TakeDeals.
+func (m *MatchingEngine) TakeL3() []*apis.L3Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l3 := m.l3
+	m.l3 = nil
+	return l3
+}
@@ -0,0 +1,735 @@
+package engine
+
+import (
+	"container/heap"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/atgane/opentd/apis"
+	"github.com/atgane/opentd/pkgs/events"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrInvalidOrder   = errors.New("invalid order")
+	ErrDuplicateOrder = errors.New("duplicate order")
+	ErrOrderNotFound  = errors.New("order not found")
+	ErrNotOrderOwner  = errors.New("order belongs to another user")
+
+	ErrPostOnlyWouldCross      = errors.New("post only order would trade on entry")
+	ErrReduceOnlyWouldIncrease = errors.New("reduce only order would increase the position")
+)
+
+const (
+	minRetryBackoff = 10 * time.Millisecond
+	maxRetryBackoff = time.Second
+)
+
+type EngineConfig struct {
+	SnapshotInterval time.Duration
+	// DayEnd is the time of day, as an offset from UTC midnight, at which DAY
+	// orders expire.
+	DayEnd time.Duration
+	// PostOnlyReprice moves a crossing post only order one tick behind the
+	// best opposite price instead of rejecting it.
+	PostOnlyReprice bool
+	// SelfTradePrevention is the mode of each user id for orders that do not
+	// choose one. Users that are not listed may trade with themselves.
+	SelfTradePrevention map[string]SelfTradeMode
+	Fees                FeeConfig
+	// HandleKey keys the hash of order ids that gives the L3 feed handles, so
+	// handles do not reveal the order of arrival. Without a key a random one
+	// is drawn, and orders replayed after a restart get other handles.
+	HandleKey string
+}
+
+// MatchingEngine is a continuous limit order book with price-time priority,
+// one book per target. Deals and order state changes are queued while events
+// are applied and handed to the callbacks by Start, so applying an event never
+// waits on them.
+type MatchingEngine struct {
+	mu               sync.Mutex
+	books            map[string]*orderBook
+	orders           map[string]*Order
+	seq              uint64
+	lastEventID      string
+	journalSeq       uint64
+	clock            int64
+	expiries         expiryQueue
+	dayEndOffset     time.Duration
+	postOnlyReprice  bool
+	selfTrade        map[string]SelfTradeMode
+	fees             FeeConfig
+	handleKey        []byte
+	pending          []*apis.GetDealStream
+	updates          []*apis.OrderUpdate
+	depth            []*apis.DepthUpdate
+	l3               []*apis.L3Message
+	notify           chan struct{}
+	done             chan struct{}
+	stopOnce         sync.Once
+	running          sync.Mutex
+	snapshotInterval time.Duration
+}
+
+func NewMatchingEngine(conf EngineConfig) (*MatchingEngine, error) {
+	if conf.SnapshotInterval < 0 {
+		return nil, fmt.Errorf("snapshot interval must not be negative")
+	}
+	if conf.DayEnd < 0 || conf.DayEnd >= 24*time.Hour {
+		return nil, fmt.Errorf("day end must be within a day")
+	}
+
+	m := new(MatchingEngine)
+	m.books = make(map[string]*orderBook)
+	m.orders = make(map[string]*Order)
+	m.notify = make(chan struct{}, 1)
+	m.done = make(chan struct{})
+	m.snapshotInterval = conf.SnapshotInterval
+	m.dayEndOffset = conf.DayEnd
+	m.postOnlyReprice = conf.PostOnlyReprice
+	m.selfTrade = make(map[string]SelfTradeMode, len(conf.SelfTradePrevention))
+	for user, mode := range conf.SelfTradePrevention {
+		if mode < SelfTradeDefault || mode > DecrementAndCancel {
+			return nil, fmt.Errorf("unknown self trade prevention mode %d for %s", mode, user)
+		}
+		m.selfTrade[user] = mode
+	}
+	if err := conf.Fees.validate(); err != nil {
+		return nil, err
+	}
+	m.fees = conf.Fees
+	m.handleKey = []byte(conf.HandleKey)
+	if len(m.handleKey) == 0 {
+		m.handleKey = make([]byte, 32)
+		if _, err := rand.Read(m.handleKey); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *MatchingEngine) AddBuy(e cloudevents.Event) error {
+	req := new(apis.BuyRequest)
+	if err := events.DataAs(e, req); err != nil {
+		return err
+	}
+
+	return m.submit(e, &Order{
+		ID:              e.ID(),
+		UserID:          req.UserId,
+		Target:          req.Target,
+		Side:            Buy,
+		Type:            orderType(req.OrderType),
+		Price:           req.Price,
+		Amount:          req.Amount,
+		TimeInForce:     TimeInForce(req.TimeInForce),
+		ExpireAt:        req.ExpireAt,
+		StopPrice:       req.StopPrice,
+		ProtectionPrice: req.ProtectionPrice,
+		MaxSlippage:     req.MaxSlippage,
+		DisplayAmount:   req.DisplayAmount,
+		PostOnly:        req.PostOnly,
+		ReduceOnly:      req.ReduceOnly,
+		SelfTrade:       SelfTradeMode(req.SelfTradePrevention),
+	})
+}
+
+func (m *MatchingEngine) AddSell(e cloudevents.Event) error {
+	req := new(apis.SellRequest)
+	if err := events.DataAs(e, req); err != nil {
+		return err
+	}
+
+	return m.submit(e, &Order{
+		ID:              e.ID(),
+		UserID:          req.UserId,
+		Target:          req.Target,
+		Side:            Sell,
+		Type:            orderType(req.OrderType),
+		Price:           req.Price,
+		Amount:          req.Amount,
+		TimeInForce:     TimeInForce(req.TimeInForce),
+		ExpireAt:        req.ExpireAt,
+		StopPrice:       req.StopPrice,
+		ProtectionPrice: req.ProtectionPrice,
+		MaxSlippage:     req.MaxSlippage,
+		DisplayAmount:   req.DisplayAmount,
+		PostOnly:        req.PostOnly,
+		ReduceOnly:      req.ReduceOnly,
+		SelfTrade:       SelfTradeMode(req.SelfTradePrevention),
+	})
+}
+
+func (m *MatchingEngine) AddCancel(e cloudevents.Event) error {
+	req := new(apis.CancelRequest)
+	if err := events.DataAs(e, req); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer m.flushMarketData()
+	m.track(e)
+
+	o, err := m.lookup(req.RequestId, req.UserId)
+	if err != nil {
+		return err
+	}
+	if req.Target != "" && req.Target != o.Target {
+		return ErrInvalidOrder
+	}
+
+	m.unlink(o)
+	o.Cancelled += o.Amount
+	o.Amount = 0
+	m.report(apis.OrderUpdateKind_UPDATE_CANCELLED, o)
+	return nil
+}
+
+func (m *MatchingEngine) AddUpdateBuy(e cloudevents.Event) error {
+	return m.update(e, Buy)
+}
+
+func (m *MatchingEngine) AddUpdateSell(e cloudevents.Event) error {
+	return m.update(e, Sell)
+}
+
+// Start hands every deal to stream, every order state change to update, every
+// depth update to depth and every L3 message to l3 in execution order, and
+// calls snapshot every SnapshotInterval. update, depth and l3 may be nil. It
+// blocks until Stop is called. Deals and order updates are retried until they
+// are handed over, holding back the ones after them, since settlement and the
+// order store must not miss any; depth and L3 messages carry sequences
+// clients resync on and are dropped on failure.
+func (m *MatchingEngine) Start(snapshot func() error, stream func(*apis.GetDealStream) (cloudevents.Event, error), update func(*apis.OrderUpdate) error, depth func(*apis.DepthUpdate) error, l3 func(*apis.L3Message) error) error {
+	if stream == nil {
+		return fmt.Errorf("stream callback is required")
+	}
+
+	m.running.Lock()
+	defer m.running.Unlock()
+
+	var tick <-chan time.Time
+	if snapshot != nil && m.snapshotInterval > 0 {
+		t := time.NewTicker(m.snapshotInterval)
+		defer t.Stop()
+		tick = t.C
+	}
+
+	for {
+		select {
+		case <-m.done:
+			return nil
+		case <-m.notify:
+			deals := m.TakeDeals()
+			for i, d := range deals {
+				err := m.retry(func() error {
+					e, err := stream(d)
+					if err != nil {
+						log.Error().
+							Err(err).
+							Str("deal_id", d.DealId).
+							Str("target", d.Target).
+							Msg("failed to stream()")
+						return err
+					}
+					log.Debug().Str("event_id", e.ID()).Str("deal_id", d.DealId).Msg("deal streamed")
+					return nil
+				})
+				if err != nil {
+					m.requeue(deals[i:], nil)
+					return nil
+				}
+			}
+			updates := m.TakeUpdates()
+			for i, u := range updates {
+				if update == nil {
+					continue
+				}
+				err := m.retry(func() error {
+					err := update(u)
+					if err != nil {
+						log.Error().
+							Err(err).
+							Str("request_id", u.Order.RequestId).
+							Str("kind", u.Kind.String()).
+							Msg("failed to update()")
+					}
+					return err
+				})
+				if err != nil {
+					m.requeue(nil, updates[i:])
+					return nil
+				}
+			}
+			for _, u := range m.TakeDepth() {
+				if depth == nil {
+					continue
+				}
+				if err := depth(u); err != nil {
+					log.Error().
+						Err(err).
+						Str("target", u.Target).
+						Uint64("seq", u.Seq).
+						Msg("failed to depth()")
+				}
+			}
+			for _, msg := range m.TakeL3() {
+				if l3 == nil {
+					continue
+				}
+				if err := l3(msg); err != nil {
+					log.Error().
+						Err(err).
+						Str("target", msg.Target).
+						Uint64("seq", msg.Seq).
+						Msg("failed to l3()")
+				}
+			}
+		case <-tick:
+			if err := snapshot(); err != nil {
+				log.Error().Err(err).Msg("failed to snapshot()")
+			}
+		}
+	}
+}
+
+// retry calls f until it succeeds, backing off between attempts. It gives up
+// only when the engine is stopped.
+func (m *MatchingEngine) retry(f func() error) error {
+	backoff := minRetryBackoff
+	for {
+		err := f()
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-m.done:
+			return err
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxRetryBackoff)
+	}
+}
+
+// Stop returns once Start has returned, so a snapshot taken after it holds
+// every deal and update that was not handed over.
+func (m *MatchingEngine) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.done)
+	})
+	m.running.Lock()
+	m.running.Unlock()
+}
+
+// submit validates and places a new order. A rejected order is reported as
+// such unless its id is taken, since the state belongs to the first order.
+func (m *MatchingEngine) submit(e cloudevents.Event, o *Order) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer m.flushMarketData()
+	m.track(e)
+
+	o.CreatedSeq = m.journalSeq
+	o.CreatedAt = m.clock
+	err := m.enter(o)
+	if err != nil && o.ID != "" && !errors.Is(err, ErrDuplicateOrder) {
+		o.Revision++
+		m.updates = append(m.updates, &apis.OrderUpdate{
+			Kind:  apis.OrderUpdateKind_UPDATE_REJECTED,
+			Order: o.state(RejectReason(err), m.clock),
+		})
+		m.wake()
+	}
+	return err
+}
+
+// enter validates o and enters it into its book. m.mu must be held.
+func (m *MatchingEngine) enter(o *Order) error {
+	if o.ID == "" || o.UserID == "" || o.Target == "" || o.Amount <= 0 {
+		return ErrInvalidOrder
+	}
+	if _, ok := m.orders[o.ID]; ok {
+		return ErrDuplicateOrder
+	}
+
+	switch o.TimeInForce {
+	case GTC, IOC, FOK, DAY:
+		if o.ExpireAt != 0 {
+			return ErrInvalidOrder
+		}
+	case GTD:
+		if o.ExpireAt <= m.clock {
+			return ErrInvalidOrder
+		}
+	default:
+		return ErrInvalidOrder
+	}
+
+	// a stop market order may wait for its trigger until it expires, but a
+	// market order never rests
+	market := o.Type == Market || o.Type == Stop
+	if market && (o.ProtectionPrice < 0 || o.MaxSlippage < 0 || o.DisplayAmount != 0) {
+		return ErrInvalidOrder
+	}
+	if o.Type == Market && (o.TimeInForce == GTD || o.TimeInForce == DAY) {
+		return ErrInvalidOrder
+	}
+	if o.TimeInForce == DAY {
+		o.ExpireAt = m.dayEnd()
+	}
+	if o.DisplayAmount < 0 {
+		return ErrInvalidOrder
+	}
+	o.Visible = 0
+	if !market {
+		o.ProtectionPrice, o.MaxSlippage = 0, 0
+		if o.Price <= 0 {
+			return ErrInvalidOrder
+		}
+	}
+
+	if o.PostOnly && (o.Type != Limit || !o.TimeInForce.rests()) {
+		return ErrInvalidOrder
+	}
+	if o.SelfTrade < SelfTradeDefault || o.SelfTrade > DecrementAndCancel {
+		return ErrInvalidOrder
+	}
+	if o.SelfTrade == SelfTradeDefault {
+		o.SelfTrade = m.selfTrade[o.UserID]
+	}
+
+	b := m.book(o.Target)
+	if o.ReduceOnly && !b.reduces(o, o.Amount) {
+		return ErrReduceOnlyWouldIncrease
+	}
+	if o.PostOnly && b.wouldCross(o) {
+		if !m.postOnlyReprice {
+			return ErrPostOnlyWouldCross
+		}
+		if o.Price = b.postOnlyPrice(o); o.Price <= 0 {
+			return ErrPostOnlyWouldCross
+		}
+	}
+
+	switch o.Type {
+	case Limit, Market:
+		o.StopPrice = 0
+		m.execute(b, o, apis.OrderUpdateKind_UPDATE_ACCEPTED)
+	case Stop, StopLimit:
+		if o.StopPrice <= 0 {
+			return ErrInvalidOrder
+		}
+		m.seq++
+		o.Seq = m.seq
+		b.stops.add(o)
+		m.orders[o.ID] = o
+		if o.ExpireAt > 0 {
+			heap.Push(&m.expiries, expiryEntry{at: o.ExpireAt, seq: o.Seq, o: o})
+		}
+		m.report(apis.OrderUpdateKind_UPDATE_ACCEPTED, o)
+	default:
+		return ErrInvalidOrder
+	}
+
+	m.trigger(b)
+	return nil
+}
+
+// execute enters a limit or market order into its book, killing FOK orders
+// that cannot fill completely. kind is reported before the order trades. m.mu
+// must be held.
+func (m *MatchingEngine) execute(b *orderBook, o *Order, kind apis.OrderUpdateKind) {
+	if o.Type == Market {
+		o.Price = b.marketLimit(o.Side, o.ProtectionPrice, o.MaxSlippage)
+	}
+	if o.TimeInForce == FOK && !b.fillable(o) {
+		m.report(kind, o)
+		m.publish([]*apis.GetDealStream{b.newCancelReport(o)})
+		m.report(apis.OrderUpdateKind_UPDATE_CANCELLED, o)
+		return
+	}
+	m.place(o, kind)
+}
+
+// trigger enters the stops of b that the last trade price reached, one at a
+// time so stops reached by the trades of a triggered stop join in the same
+// deterministic order. m.mu must be held.
+func (m *MatchingEngine) trigger(b *orderBook) {
+	for {
+		o := b.stops.next(b.lastPrice)
+		if o == nil {
+			return
+		}
+		delete(m.orders, o.ID)
+
+		if o.Type == Stop {
+			o.Type = Market
+		} else {
+			o.Type = Limit
+		}
+		m.execute(b, o, apis.OrderUpdateKind_UPDATE_TRIGGERED)
+	}
+}
+
+// unlink removes a resting or waiting stop order. m.mu must be held.
+func (m *MatchingEngine) unlink(o *Order) {
+	b := m.books[o.Target]
+	if o.Type == Stop || o.Type == StopLimit {
+		b.stops.remove(o)
+	} else {
+		b.remove(o)
+	}
+	delete(m.orders, o.ID)
+}
+
+// book returns the book of target, creating it if needed. m.mu must be held.
+func (m *MatchingEngine) book(target string) *orderBook {
+	b, ok := m.books[target]
+	if !ok {
+		b = newOrderBook(target, m.fees.schedule(target), m.handleKey)
+		m.books[target] = b
+	}
+	return b
+}
+
+// place matches o against its book and rests any remainder, or cancels it for
+// market orders and orders whose time in force does not rest. kind is reported
+// before o trades, then every order the match changed. m.mu must be held.
+func (m *MatchingEngine) place(o *Order, kind apis.OrderUpdateKind) {
+	b := m.book(o.Target)
+
+	m.seq++
+	o.Seq = m.seq
+	m.report(kind, o)
+
+	taker := changeOf(o)
+	deals, changes := b.match(o)
+	for _, c := range changes {
+		if c.o.Amount == 0 {
+			delete(m.orders, c.o.ID)
+		}
+	}
+	for i := len(deals) - 1; i >= 0; i-- {
+		if deals[i].Amount > 0 {
+			b.lastPrice = deals[i].Price
+			break
+		}
+	}
+	if o.Amount > 0 {
+		if o.Type == Market || !o.TimeInForce.rests() {
+			deals = append(deals, b.newCancelReport(o))
+		} else {
+			o.reveal()
+			b.rest(o)
+			m.orders[o.ID] = o
+			if o.ExpireAt > 0 {
+				heap.Push(&m.expiries, expiryEntry{at: o.ExpireAt, seq: o.Seq, o: o})
+			}
+		}
+	}
+
+	m.publish(deals)
+	for _, c := range changes {
+		m.report(c.kind(), c.o)
+	}
+	if taker.changed() {
+		m.report(taker.kind(), o)
+	}
+}
+
+func (m *MatchingEngine) update(e cloudevents.Event, side Side) error {
+	req := new(apis.UpdateRequest)
+	if err := events.DataAs(e, req); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer m.flushMarketData()
+	m.track(e)
+
+	o, err := m.lookup(req.RequestId, req.UserId)
+	if err != nil {
+		return err
+	}
+	// the order keeps its state, the update only tells its owner why the
+	// amend was refused
+	if err := m.amend(o, req, side); err != nil {
+		o.Revision++
+		s := o.state(apis.RejectReason_NO_REASON, m.clock)
+		s.RejectReason = RejectReason(err)
+		m.updates = append(m.updates, &apis.OrderUpdate{
+			Kind:  apis.OrderUpdateKind_UPDATE_REJECTED,
+			Order: s,
+		})
+		m.wake()
+		return err
+	}
+	return nil
+}
+
+// amend applies req to the resting order o. m.mu must be held.
+func (m *MatchingEngine) amend(o *Order, req *apis.UpdateRequest, side Side) error {
+	if req.Amount <= 0 || req.Price <= 0 {
+		return ErrInvalidOrder
+	}
+	// waiting stops have no place in the book to keep, they are cancelled and
+	// submitted again instead
+	if o.Side != side || o.Type != Limit || (req.Target != "" && req.Target != o.Target) {
+		return ErrInvalidOrder
+	}
+
+	b := m.books[o.Target]
+	price := req.Price
+	if probe := (&Order{Side: o.Side, Price: price}); o.PostOnly && b.wouldCross(probe) {
+		if !m.postOnlyReprice {
+			return ErrPostOnlyWouldCross
+		}
+		if price = b.postOnlyPrice(probe); price <= 0 {
+			return ErrPostOnlyWouldCross
+		}
+	}
+
+	// shrinking an order at the same price keeps its place in the queue,
+	// anything else is treated as a new arrival and may trade immediately.
+	if price == o.Price && req.Amount <= o.Amount {
+		o.Amount = req.Amount
+		o.Visible = min(o.Visible, o.Amount)
+		b.mark(o.Side, o.Price)
+		b.feed(apis.L3Action_L3_MODIFY, o, 0, "")
+		m.report(apis.OrderUpdateKind_UPDATE_AMENDED, o)
+		return nil
+	}
+
+	if o.ReduceOnly && req.Amount > o.Amount && !b.reduces(o, req.Amount) {
+		return ErrReduceOnlyWouldIncrease
+	}
+
+	m.unlink(o)
+	o.Price = price
+	o.Amount = req.Amount
+	m.place(o, apis.OrderUpdateKind_UPDATE_AMENDED)
+	m.trigger(b)
+	return nil
+}
+
+func orderType(t apis.OrderType) OrderType {
+	switch t {
+	case apis.OrderType_LIMIT:
+		return Limit
+	case apis.OrderType_MARKET:
+		return Market
+	case apis.OrderType_STOP:
+		return Stop
+	case apis.OrderType_STOP_LIMIT:
+		return StopLimit
+	}
+	return -1
+}
+
+// track records e as the last consumed event and expires the orders that
+// expired by its time. m.mu must be held.
+func (m *MatchingEngine) track(e cloudevents.Event) {
+	m.lastEventID = e.ID()
+	if seq, ok := events.Sequence(e); ok {
+		m.journalSeq = seq
+	}
+	m.advance(e)
+}
+
+func (m *MatchingEngine) lookup(id, userID string) (*Order, error) {
+	o, ok := m.orders[id]
+	if !ok {
+		return nil, ErrOrderNotFound
+	}
+	if o.UserID != userID {
+		return nil, ErrNotOrderOwner
+	}
+	return o, nil
+}
+
+// publish queues deals for Start. m.mu must be held.
+func (m *MatchingEngine) publish(deals []*apis.GetDealStream) {
+	if len(deals) == 0 {
+		return
+	}
+
+	m.pending = append(m.pending, deals...)
+	m.wake()
+}
+
+// report queues the state of o after a change of kind for Start. m.mu must be
+// held.
+func (m *MatchingEngine) report(kind apis.OrderUpdateKind, o *Order) {
+	o.Revision++
+	m.updates = append(m.updates, &apis.OrderUpdate{
+		Kind:  kind,
+		Order: o.state(apis.RejectReason_NO_REASON, m.clock),
+	})
+	m.wake()
+}
+
+func (m *MatchingEngine) wake() {
+	select {
+	case m.notify <- struct{}{}:
+	default:
+	}
+}
+
+// requeue puts back what Start took but could not hand over before it was
+// stopped, ahead of what was queued since.
+func (m *MatchingEngine) requeue(deals []*apis.GetDealStream, updates []*apis.OrderUpdate) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pending = append(append([]*apis.GetDealStream(nil), deals...), m.pending...)
+	m.updates = append(append([]*apis.OrderUpdate(nil), updates...), m.updates...)
+}
+
+// TakeDeals returns and clears the deals that have not been streamed yet. Start
+// uses it internally; callers that drive the engine without Start, such as
+// journal replay, can use it to collect deals directly.
+func (m *MatchingEngine) TakeDeals() []*apis.GetDealStream {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deals := m.pending
+	m.pending = nil
+	return deals
+}
+
+// TakeUpdates returns and clears the order state changes that have not been
+// handed to Start's update callback yet, like TakeDeals.
+func (m *MatchingEngine) TakeUpdates() []*apis.OrderUpdate {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	updates := m.updates
+	m.updates = nil
+	return updates
+}
+
+// RejectReason maps an error returned while applying an order event to the
+// reason reported to its submitter.
+func RejectReason(err error) apis.RejectReason {
+	switch {
+	case errors.Is(err, ErrDuplicateOrder):
+		return apis.RejectReason_DUPLICATE_ORDER
+	case errors.Is(err, ErrOrderNotFound):
+		return apis.RejectReason_ORDER_NOT_FOUND
+	case errors.Is(err, ErrNotOrderOwner):
+		return apis.RejectReason_NOT_ORDER_OWNER
+	case errors.Is(err, ErrPostOnlyWouldCross):
+		return apis.RejectReason_POST_ONLY_WOULD_CROSS
+	case errors.Is(err, ErrReduceOnlyWouldIncrease):
+		return apis.RejectReason_REDUCE_ONLY_WOULD_INCREASE
+	}
+	return apis.RejectReason_INVALID_ORDER
+}
@@ -0,0 +1,1058 @@
+package engine_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/atgane/opentd/apis"
+	"github.com/atgane/opentd/pkgs/engine"
+	"github.com/atgane/opentd/pkgs/events"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/require"
+)
+
+var testMatchingScenario = []matchingScenario{
+	{"rest without cross", testRestWithoutCross},
+	{"price time priority", testPriceTimePriority},
+	{"partial fill", testPartialFill},
+	{"cancel", testCancel},
+	{"update", testUpdate},
+	{"snapshot restore", testSnapshotRestore},
+	{"market sweep", testMarketSweep},
+	{"market protection", testMarketProtection},
+	{"immediate or cancel", testImmediateOrCancel},
+	{"fill or kill", testFillOrKill},
+	{"good till date", testGoodTillDate},
+	{"day order", testDayOrder},
+	{"stop order", testStopOrder},
+	{"stop cascade", testStopCascade},
+	{"stop expiry", testStopExpiry},
+	{"iceberg", testIceberg},
+	{"post only", testPostOnly},
+	{"reduce only", testReduceOnly},
+	{"self trade prevention", testSelfTradePrevention},
+	{"order lifecycle", testOrderLifecycle},
+	{"depth", testDepth},
+	{"l3 feed", testL3Feed},
+	{"fees", testFees},
+}
+
+type matchingScenario struct {
+	name string
+	fn   func(t *testing.T, ts *testState)
+}
+
+type testState struct {
+	m       *engine.MatchingEngine
+	deals   chan *apis.GetDealStream
+	updates chan *apis.OrderUpdate
+	depth   chan *apis.DepthUpdate
+	l3      chan *apis.L3Message
+}
+
+func newTestState(m *engine.MatchingEngine) *testState {
+	ts := &testState{m: m, deals: make(chan *apis.GetDealStream, 16), updates: make(chan *apis.OrderUpdate, 64), depth: make(chan *apis.DepthUpdate, 64), l3: make(chan *apis.L3Message, 64)}
+	go m.Start(nil, func(d *apis.GetDealStream) (cloudevents.Event, error) {
+		ts.deals <- d
+		return cloudevents.NewEvent(), nil
+	}, func(u *apis.OrderUpdate) error {
+		ts.updates <- u
+		return nil
+	}, func(u *apis.DepthUpdate) error {
+		ts.depth <- u
+		return nil
+	}, func(msg *apis.L3Message) error {
+		ts.l3 <- msg
+		return nil
+	})
+	return ts
+}
+
+func TestMatchingEngine(t *testing.T) {
+	for idx := range testMatchingScenario {
+		t.Run(testMatchingScenario[idx].name, func(t *testing.T) {
+			m, err := engine.NewMatchingEngine(engine.EngineConfig{})
+			require.NoError(t, err)
+
+			ts := newTestState(m)
+			defer m.Stop()
+
+			testMatchingScenario[idx].fn(t, ts)
+		})
+	}
+}
+
+func TestMatchingEngineRetry(t *testing.T) {
+	m, err := engine.NewMatchingEngine(engine.EngineConfig{})
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	failures, down := 2, false
+	deals := make(chan *apis.GetDealStream, 4)
+	go m.Start(nil, func(d *apis.GetDealStream) (cloudevents.Event, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if down || failures > 0 {
+			failures--
+			return cloudevents.Event{}, errors.New("broker down")
+		}
+		deals <- d
+		return cloudevents.NewEvent(), nil
+	}, nil, nil, nil)
+	ts := &testState{m: m, deals: deals}
+
+	// the deal is streamed once the broker is back
+	sell(t, ts, "s1", "user1", 2, 10)
+	buy(t, ts, "b1", "user2", 1, 10)
+	require.Equal(t, "b1", nextDeal(t, ts).BuyOrderId)
+
+	// a deal that is still failing when the engine stops stays queued for the
+	// snapshot
+	mu.Lock()
+	down = true
+	mu.Unlock()
+	buy(t, ts, "b2", "user2", 1, 10)
+	time.Sleep(50 * time.Millisecond)
+	m.Stop()
+	snap := m.Snapshot()
+	require.Len(t, snap.PendingDeals, 1)
+	require.Equal(t, "b2", snap.PendingDeals[0].BuyOrderId)
+}
+
+func newEvent(t *testing.T, id, typ string, data interface{}) cloudevents.Event {
+	t.Helper()
+
+	e := cloudevents.NewEvent()
+	e.SetID(id)
+	e.SetType(typ)
+	e.SetSource(events.FrontendSource)
+	require.NoError(t, e.SetData(cloudevents.ApplicationJSON, data))
+	return e
+}
+
+func buy(t *testing.T, ts *testState, id, user string, amount, price int64) {
+	t.Helper()
+
+	req := &apis.BuyRequest{UserId: user, Target: "t", Amount: amount, Price: price}
+	require.NoError(t, ts.m.AddBuy(newEvent(t, id, events.BuyType, req)))
+}
+
+func sell(t *testing.T, ts *testState, id, user string, amount, price int64) {
+	t.Helper()
+
+	req := &apis.SellRequest{UserId: user, Target: "t", Amount: amount, Price: price}
+	require.NoError(t, ts.m.AddSell(newEvent(t, id, events.SellType, req)))
+}
+
+// nextUpdate returns the next order update after checking its kind and order.
+func nextUpdate(t *testing.T, ts *testState, kind apis.OrderUpdateKind, id string) *apis.Order {
+	t.Helper()
+
+	select {
+	case u := <-ts.updates:
+		require.Equal(t, kind, u.Kind)
+		require.Equal(t, id, u.Order.RequestId)
+		return u.Order
+	case <-time.After(time.Second):
+		require.FailNow(t, "order update was not handed over")
+		return nil
+	}
+}
+
+func nextDeal(t *testing.T, ts *testState) *apis.GetDealStream {
+	t.Helper()
+
+	select {
+	case d := <-ts.deals:
+		return d
+	case <-time.After(time.Second):
+		require.FailNow(t, "deal was not streamed")
+		return nil
+	}
+}
+
+func testRestWithoutCross(t *testing.T, ts *testState) {
+	buy(t, ts, "b1", "user1", 1, 10)
+	sell(t, ts, "s1", "user2", 1, 11)
+
+	// both orders rest, so the next crossing sell trades with b1 at its price
+	sell(t, ts, "s2", "user3", 1, 10)
+
+	d := nextDeal(t, ts)
+	require.Equal(t, "user1", d.BuyerId)
+	require.Equal(t, "user3", d.SellerId)
+	require.Equal(t, int64(10), d.Price)
+	require.Equal(t, int64(1), d.Amount)
+}
+
+func testPriceTimePriority(t *testing.T, ts *testState) {
+	sell(t, ts, "s1", "user1", 1, 12)
+	sell(t, ts, "s2", "user2", 1, 11)
+	sell(t, ts, "s3", "user3", 1, 11)
+	buy(t, ts, "b1", "user4", 3, 12)
+
+	expected := []struct {
+		seller string
+		price  int64
+	}{{"user2", 11}, {"user3", 11}, {"user1", 12}}
+	for _, ex := range expected {
+		d := nextDeal(t, ts)
+		require.Equal(t, ex.seller, d.SellerId)
+		require.Equal(t, ex.price, d.Price)
+		require.Equal(t, "user4", d.BuyerId)
+		require.Equal(t, "t", d.Target)
+	}
+}
+
+func testPartialFill(t *testing.T, ts *testState) {
+	buy(t, ts, "b1", "user1", 5, 10)
+	sell(t, ts, "s1", "user2", 3, 9)
+	sell(t, ts, "s2", "user3", 4, 10)
+
+	d := nextDeal(t, ts)
+	require.Equal(t, int64(3), d.Amount)
+	require.Equal(t, int64(10), d.Price)
+
+	d = nextDeal(t, ts)
+	require.Equal(t, int64(2), d.Amount)
+	require.Equal(t, "user3", d.SellerId)
+
+	// the remaining 2 of s2 rest on the ask side
+	buy(t, ts, "b2", "user4", 5, 10)
+
+	d = nextDeal(t, ts)
+	require.Equal(t, int64(2), d.Amount)
+	require.Equal(t, "user3", d.SellerId)
+	require.Equal(t, "user4", d.BuyerId)
+}
+
+func testCancel(t *testing.T, ts *testState) {
+	sell(t, ts, "s1", "user1", 1, 10)
+	sell(t, ts, "s2", "user2", 1, 11)
+
+	cancel := &apis.CancelRequest{UserId: "user2", RequestId: "s1"}
+	require.ErrorIs(t, ts.m.AddCancel(newEvent(t, "c0", events.CancelType, cancel)), engine.ErrNotOrderOwner)
+
+	cancel.UserId = "user1"
+	require.NoError(t, ts.m.AddCancel(newEvent(t, "c1", events.CancelType, cancel)))
+	require.ErrorIs(t, ts.m.AddCancel(newEvent(t, "c2", events.CancelType, cancel)), engine.ErrOrderNotFound)
+
+	buy(t, ts, "b1", "user3", 1, 11)
+
+	d := nextDeal(t, ts)
+	require.Equal(t, "user2", d.SellerId)
+	require.Equal(t, int64(11), d.Price)
+}
+
+func testUpdate(t *testing.T, ts *testState) {
+	sell(t, ts, "s1", "user1", 2, 10)
+	sell(t, ts, "s2", "user2", 2, 10)
+
+	// shrinking keeps priority
+	update := &apis.UpdateRequest{UserId: "user1", RequestId: "s1", Target: "t", Amount: 1, Price: 10}
+	require.NoError(t, ts.m.AddUpdateSell(newEvent(t, "u1", events.UpdateSellType, update)))
+
+	// repricing into the bid trades immediately
+	buy(t, ts, "b1", "user3", 1, 9)
+	update = &apis.UpdateRequest{UserId: "user2", RequestId: "s2", Target: "t", Amount: 2, Price: 9}
+	require.NoError(t, ts.m.AddUpdateSell(newEvent(t, "u2", events.UpdateSellType, update)))
+
+	update = &apis.UpdateRequest{UserId: "user2", RequestId: "s2", Target: "t", Amount: 1, Price: 9}
+	require.ErrorIs(t, ts.m.AddUpdateBuy(newEvent(t, "u3", events.UpdateBuyType, update)), engine.ErrInvalidOrder)
+
+	buy(t, ts, "b2", "user3", 5, 10)
+
+	expected := []struct {
+		seller string
+		amount int64
+		price  int64
+	}{{"user2", 1, 9}, {"user2", 1, 9}, {"user1", 1, 10}}
+	for _, ex := range expected {
+		d := nextDeal(t, ts)
+		require.Equal(t, ex.seller, d.SellerId)
+		require.Equal(t, ex.amount, d.Amount)
+		require.Equal(t, ex.price, d.Price)
+	}
+}
+
+func testSnapshotRestore(t *testing.T, ts *testState) {
+	sell(t, ts, "s1", "user1", 2, 10)
+	sell(t, ts, "s2", "user2", 2, 10)
+	buy(t, ts, "b1", "user3", 1, 9)
+	buy(t, ts, "b2", "user4", 1, 10)
+	nextDeal(t, ts)
+
+	snap := ts.m.Snapshot()
+	require.Equal(t, engine.SnapshotVersion, snap.Version)
+	require.Equal(t, "b2", snap.LastEventID)
+	require.Len(t, snap.Books, 1)
+	require.Len(t, snap.Books[0].Bids, 1)
+	require.Len(t, snap.Books[0].Asks, 2)
+
+	b, err := json.Marshal(snap)
+	require.NoError(t, err)
+	restored := new(engine.Snapshot)
+	require.NoError(t, json.Unmarshal(b, restored))
+
+	m, err := engine.NewMatchingEngine(engine.EngineConfig{})
+	require.NoError(t, err)
+	require.NoError(t, m.Restore(restored))
+	require.Equal(t, snap, m.Snapshot())
+
+	restoredState := newTestState(m)
+	defer m.Stop()
+
+	// both engines continue identically after the snapshot point
+	for _, s := range []*testState{ts, restoredState} {
+		buy(t, s, "b3", "user5", 3, 10)
+		expected := []struct {
+			seller string
+			amount int64
+		}{{"user1", 1}, {"user2", 2}}
+		for idx, ex := range expected {
+			d := nextDeal(t, s)
+			require.Equal(t, ex.seller, d.SellerId)
+			require.Equal(t, ex.amount, d.Amount)
+			require.Equal(t, fmt.Sprintf("t-%d", idx+2), d.DealId)
+		}
+	}
+}
+
+func testMarketSweep(t *testing.T, ts *testState) {
+	sell(t, ts, "s1", "user1", 1, 10)
+	sell(t, ts, "s2", "user2", 2, 12)
+
+	req := &apis.BuyRequest{UserId: "user3", Target: "t", Amount: 5, OrderType: apis.OrderType_MARKET}
+	require.NoError(t, ts.m.AddBuy(newEvent(t, "b1", events.BuyType, req)))
+
+	d := nextDeal(t, ts)
+	require.Equal(t, int64(1), d.Amount)
+	require.Equal(t, int64(10), d.Price)
+	require.Equal(t, "b1", d.BuyOrderId)
+	require.Equal(t, "s1", d.SellOrderId)
+
+	d = nextDeal(t, ts)
+	require.Equal(t, int64(2), d.Amount)
+	require.Equal(t, int64(12), d.Price)
+
+	// the unfilled remainder is reported as cancelled and never rests
+	d = nextDeal(t, ts)
+	require.Equal(t, int64(0), d.Amount)
+	require.Equal(t, int64(2), d.CancelledAmount)
+	require.Equal(t, "user3", d.BuyerId)
+	require.Equal(t, "b1", d.BuyOrderId)
+
+	s := ts.m.Snapshot()
+	require.Len(t, s.Books, 1)
+	require.Empty(t, s.Books[0].Bids)
+	require.Empty(t, s.Books[0].Asks)
+}
+
+func testMarketProtection(t *testing.T, ts *testState) {
+	buy(t, ts, "b1", "user1", 1, 20)
+	buy(t, ts, "b2", "user2", 1, 19)
+	buy(t, ts, "b3", "user3", 1, 17)
+
+	// slippage 2 from the best bid allows 19, the protection price allows 17,
+	// so the tighter bound stops the sweep before b3
+	req := &apis.SellRequest{UserId: "user4", Target: "t", Amount: 3, OrderType: apis.OrderType_MARKET, ProtectionPrice: 17, MaxSlippage: 2}
+	require.NoError(t, ts.m.AddSell(newEvent(t, "s1", events.SellType, req)))
+
+	require.Equal(t, "b1", nextDeal(t, ts).BuyOrderId)
+	require.Equal(t, "b2", nextDeal(t, ts).BuyOrderId)
+
+	d := nextDeal(t, ts)
+	require.Equal(t, int64(1), d.CancelledAmount)
+	require.Equal(t, "user4", d.SellerId)
+
+	invalid := &apis.SellRequest{UserId: "user4", Target: "t", Amount: 1, OrderType: apis.OrderType_MARKET, MaxSlippage: -1}
+	require.ErrorIs(t, ts.m.AddSell(newEvent(t, "s2", events.SellType, invalid)), engine.ErrInvalidOrder)
+}
+
+func timedEvent(t *testing.T, id, typ string, at time.Time, data interface{}) cloudevents.Event {
+	t.Helper()
+
+	e := newEvent(t, id, typ, data)
+	e.SetTime(at)
+	return e
+}
+
+func testImmediateOrCancel(t *testing.T, ts *testState) {
+	sell(t, ts, "s1", "user1", 1, 10)
+
+	req := &apis.BuyRequest{UserId: "user2", Target: "t", Amount: 3, Price: 10, TimeInForce: apis.TimeInForce_IOC}
+	require.NoError(t, ts.m.AddBuy(newEvent(t, "b1", events.BuyType, req)))
+
+	require.Equal(t, int64(1), nextDeal(t, ts).Amount)
+	d := nextDeal(t, ts)
+	require.Equal(t, int64(2), d.CancelledAmount)
+	require.Equal(t, "b1", d.BuyOrderId)
+
+	// nothing of b1 rests, so this sell rests too
+	sell(t, ts, "s2", "user3", 1, 10)
+	require.Len(t, ts.m.Snapshot().Books[0].Asks, 1)
+}
+
+func testFillOrKill(t *testing.T, ts *testState) {
+	sell(t, ts, "s1", "user1", 1, 10)
+	sell(t, ts, "s2", "user2", 1, 11)
+
+	// only one lot is available at 10, so the whole order is killed
+	req := &apis.BuyRequest{UserId: "user3", Target: "t", Amount: 2, Price: 10, TimeInForce: apis.TimeInForce_FOK}
+	require.NoError(t, ts.m.AddBuy(newEvent(t, "b1", events.BuyType, req)))
+	d := nextDeal(t, ts)
+	require.Equal(t, int64(0), d.Amount)
+	require.Equal(t, int64(2), d.CancelledAmount)
+	require.Len(t, ts.m.Snapshot().Books[0].Asks, 2)
+
+	req.Price = 11
+	require.NoError(t, ts.m.AddBuy(newEvent(t, "b2", events.BuyType, req)))
+	require.Equal(t, "s1", nextDeal(t, ts).SellOrderId)
+	require.Equal(t, "s2", nextDeal(t, ts).SellOrderId)
+}
+
+func testGoodTillDate(t *testing.T, ts *testState) {
+	now := time.UnixMilli(1_700_000_000_000)
+
+	expired := &apis.BuyRequest{UserId: "user1", Target: "t", Amount: 1, Price: 10, TimeInForce: apis.TimeInForce_GTD, ExpireAt: now.UnixMilli()}
+	require.NoError(t, ts.m.AddBuy(timedEvent(t, "b0", events.BuyType, now, &apis.BuyRequest{UserId: "user1", Target: "t", Amount: 1, Price: 1})))
+	require.ErrorIs(t, ts.m.AddBuy(timedEvent(t, "b1", events.BuyType, now, expired)), engine.ErrInvalidOrder)
+
+	req := &apis.BuyRequest{UserId: "user1", Target: "t", Amount: 1, Price: 10, TimeInForce: apis.TimeInForce_GTD, ExpireAt: now.Add(time.Minute).UnixMilli()}
+	require.NoError(t, ts.m.AddBuy(timedEvent(t, "b2", events.BuyType, now, req)))
+
+	next, ok := ts.m.NextExpiry()
+	require.True(t, ok)
+	require.Equal(t, req.ExpireAt, next.UnixMilli())
+
+	require.NoError(t, ts.m.AddExpire(timedEvent(t, "x1", events.ExpireType, now.Add(30*time.Second), nil)))
+	require.Len(t, ts.m.Snapshot().Books[0].Bids, 2)
+
+	require.NoError(t, ts.m.AddExpire(timedEvent(t, "x2", events.ExpireType, now.Add(time.Minute), nil)))
+	d := nextDeal(t, ts)
+	require.Equal(t, "b2", d.BuyOrderId)
+	require.Equal(t, int64(1), d.CancelledAmount)
+
+	// an expired order cannot trade even before the sweeper would have run
+	sell(t, ts, "s1", "user2", 1, 10)
+	require.Len(t, ts.m.Snapshot().Books[0].Asks, 1)
+	_, ok = ts.m.NextExpiry()
+	require.False(t, ok)
+}
+
+func testDayOrder(t *testing.T, ts *testState) {
+	morning := time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)
+
+	req := &apis.SellRequest{UserId: "user1", Target: "t", Amount: 1, Price: 10, TimeInForce: apis.TimeInForce_DAY}
+	require.NoError(t, ts.m.AddSell(timedEvent(t, "s1", events.SellType, morning, req)))
+
+	next, ok := ts.m.NextExpiry()
+	require.True(t, ok)
+	require.Equal(t, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), next.UTC())
+
+	// the expiry survives a snapshot
+	s := ts.m.Snapshot()
+	m, err := engine.NewMatchingEngine(engine.EngineConfig{})
+	require.NoError(t, err)
+	require.NoError(t, m.Restore(s))
+	require.NoError(t, m.AddExpire(timedEvent(t, "x1", events.ExpireType, next, nil)))
+	d := m.TakeDeals()
+	require.Len(t, d, 1)
+	require.Equal(t, "s1", d[0].SellOrderId)
+}
+
+func stop(t *testing.T, ts *testState, id, user string, side engine.Side, amount, stopPrice, price int64) {
+	t.Helper()
+
+	orderType := apis.OrderType_STOP
+	if price > 0 {
+		orderType = apis.OrderType_STOP_LIMIT
+	}
+	if side == engine.Buy {
+		req := &apis.BuyRequest{UserId: user, Target: "t", Amount: amount, Price: price, OrderType: orderType, StopPrice: stopPrice}
+		require.NoError(t, ts.m.AddBuy(newEvent(t, id, events.BuyType, req)))
+		return
+	}
+	req := &apis.SellRequest{UserId: user, Target: "t", Amount: amount, Price: price, OrderType: orderType, StopPrice: stopPrice}
+	require.NoError(t, ts.m.AddSell(newEvent(t, id, events.SellType, req)))
+}
+
+func testStopOrder(t *testing.T, ts *testState) {
+	buy(t, ts, "b1", "user1", 5, 9)
+	sell(t, ts, "s1", "user2", 1, 10)
+	stop(t, ts, "st1", "user3", engine.Sell, 2, 10, 0)
+
+	// the stop waits off the book until a trade happens at 10 or below
+	s := ts.m.Snapshot()
+	require.Len(t, s.Books[0].Stops, 1)
+	require.Len(t, s.Books[0].Asks, 1)
+
+	buy(t, ts, "b2", "user4", 1, 10)
+	require.Equal(t, "s1", nextDeal(t, ts).SellOrderId)
+
+	d := nextDeal(t, ts)
+	require.Equal(t, "st1", d.SellOrderId)
+	require.Equal(t, "b1", d.BuyOrderId)
+	require.Equal(t, int64(2), d.Amount)
+	require.Equal(t, int64(9), d.Price)
+	require.Empty(t, ts.m.Snapshot().Books[0].Stops)
+
+	// a stop limit whose trigger was already reached enters right away and
+	// rests at its limit price
+	stop(t, ts, "st2", "user3", engine.Sell, 1, 12, 11)
+	s = ts.m.Snapshot()
+	require.Empty(t, s.Books[0].Stops)
+	require.Equal(t, "st2", s.Books[0].Asks[0].ID)
+	require.Equal(t, engine.Limit, s.Books[0].Asks[0].Type)
+}
+
+func testStopExpiry(t *testing.T, ts *testState) {
+	now := time.UnixMilli(1_700_000_000_000)
+
+	market := &apis.BuyRequest{UserId: "user1", Target: "t", Amount: 1, OrderType: apis.OrderType_MARKET, TimeInForce: apis.TimeInForce_DAY}
+	require.ErrorIs(t, ts.m.AddBuy(timedEvent(t, "b1", events.BuyType, now, market)), engine.ErrInvalidOrder)
+
+	// stop market orders wait for their trigger until they expire
+	gtd := &apis.BuyRequest{UserId: "user1", Target: "t", Amount: 1, OrderType: apis.OrderType_STOP, StopPrice: 12, TimeInForce: apis.TimeInForce_GTD, ExpireAt: now.Add(time.Minute).UnixMilli()}
+	require.NoError(t, ts.m.AddBuy(timedEvent(t, "st1", events.BuyType, now, gtd)))
+	day := &apis.SellRequest{UserId: "user2", Target: "t", Amount: 1, OrderType: apis.OrderType_STOP, StopPrice: 8, TimeInForce: apis.TimeInForce_DAY}
+	require.NoError(t, ts.m.AddSell(timedEvent(t, "st2", events.SellType, now, day)))
+	require.Len(t, ts.m.Snapshot().Books[0].Stops, 2)
+
+	require.NoError(t, ts.m.AddExpire(timedEvent(t, "x1", events.ExpireType, now.Add(time.Minute), nil)))
+	d := nextDeal(t, ts)
+	require.Equal(t, "st1", d.BuyOrderId)
+	require.Equal(t, int64(1), d.CancelledAmount)
+	require.Len(t, ts.m.Snapshot().Books[0].Stops, 1)
+
+	next, ok := ts.m.NextExpiry()
+	require.True(t, ok)
+	require.NoError(t, ts.m.AddExpire(timedEvent(t, "x2", events.ExpireType, next, nil)))
+	d = nextDeal(t, ts)
+	require.Equal(t, "st2", d.SellOrderId)
+	require.Empty(t, ts.m.Snapshot().Books[0].Stops)
+}
+
+func testStopCascade(t *testing.T, ts *testState) {
+	sell(t, ts, "s1", "user1", 1, 10)
+	sell(t, ts, "s2", "user1", 1, 11)
+	sell(t, ts, "s3", "user1", 1, 12)
+	sell(t, ts, "s4", "user1", 1, 13)
+
+	// st2 and st3 share a trigger and fire in arrival order after st1, which
+	// has the lower trigger; st1's own trade at 11 then reaches st4
+	stop(t, ts, "st4", "user2", engine.Buy, 1, 11, 0)
+	stop(t, ts, "st2", "user3", engine.Buy, 1, 10, 0)
+	stop(t, ts, "st3", "user4", engine.Buy, 1, 10, 0)
+	stop(t, ts, "st1", "user5", engine.Buy, 1, 9, 0)
+
+	snap := ts.m.Snapshot()
+	ids := make([]string, 0, len(snap.Books[0].Stops))
+	for _, o := range snap.Books[0].Stops {
+		ids = append(ids, o.ID)
+	}
+	require.Equal(t, []string{"st1", "st2", "st3", "st4"}, ids)
+
+	buy(t, ts, "b1", "user6", 1, 10)
+
+	expected := []struct {
+		buy   string
+		price int64
+	}{{"b1", 10}, {"st1", 11}, {"st2", 12}, {"st3", 13}}
+	for _, ex := range expected {
+		d := nextDeal(t, ts)
+		require.Equal(t, ex.buy, d.BuyOrderId)
+		require.Equal(t, ex.price, d.Price)
+	}
+
+	// st4 fired last with an empty ask side, so its market order is cancelled
+	d := nextDeal(t, ts)
+	require.Equal(t, "st4", d.BuyOrderId)
+	require.Equal(t, int64(1), d.CancelledAmount)
+}
+
+func testIceberg(t *testing.T, ts *testState) {
+	req := &apis.SellRequest{UserId: "user1", Target: "t", Amount: 10, Price: 10, DisplayAmount: 3}
+	require.NoError(t, ts.m.AddSell(newEvent(t, "s1", events.SellType, req)))
+	sell(t, ts, "s2", "user2", 2, 10)
+
+	s := ts.m.Snapshot()
+	require.Equal(t, int64(3), s.Books[0].Asks[0].Visible)
+
+	// the first slice fills, the refill goes behind s2
+	buy(t, ts, "b1", "user3", 4, 10)
+	d := nextDeal(t, ts)
+	require.Equal(t, "s1", d.SellOrderId)
+	require.Equal(t, int64(3), d.Amount)
+	d = nextDeal(t, ts)
+	require.Equal(t, "s2", d.SellOrderId)
+	require.Equal(t, int64(1), d.Amount)
+
+	s = ts.m.Snapshot()
+	require.Equal(t, "s2", s.Books[0].Asks[0].ID)
+	require.Equal(t, "s1", s.Books[0].Asks[1].ID)
+	require.Equal(t, int64(7), s.Books[0].Asks[1].Amount)
+	require.Equal(t, int64(3), s.Books[0].Asks[1].Visible)
+
+	// a large taker keeps refilling the iceberg slice by slice
+	buy(t, ts, "b2", "user3", 8, 10)
+	expected := []struct {
+		sell   string
+		amount int64
+	}{{"s2", 1}, {"s1", 3}, {"s1", 3}, {"s1", 1}}
+	for _, ex := range expected {
+		d := nextDeal(t, ts)
+		require.Equal(t, ex.sell, d.SellOrderId)
+		require.Equal(t, ex.amount, d.Amount)
+	}
+	require.Empty(t, ts.m.Snapshot().Books[0].Asks)
+}
+
+func testPostOnly(t *testing.T, ts *testState) {
+	sell(t, ts, "s1", "user1", 1, 10)
+
+	req := &apis.BuyRequest{UserId: "user2", Target: "t", Amount: 1, Price: 10, PostOnly: true}
+	require.ErrorIs(t, ts.m.AddBuy(newEvent(t, "b1", events.BuyType, req)), engine.ErrPostOnlyWouldCross)
+
+	req.Price = 9
+	require.NoError(t, ts.m.AddBuy(newEvent(t, "b2", events.BuyType, req)))
+
+	// repricing it across the spread is rejected as well, and b2 stays open
+	update := &apis.UpdateRequest{UserId: "user2", RequestId: "b2", Target: "t", Amount: 1, Price: 11}
+	require.ErrorIs(t, ts.m.AddUpdateBuy(newEvent(t, "u1", events.UpdateBuyType, update)), engine.ErrPostOnlyWouldCross)
+	nextUpdate(t, ts, apis.OrderUpdateKind_UPDATE_ACCEPTED, "s1")
+	nextUpdate(t, ts, apis.OrderUpdateKind_UPDATE_REJECTED, "b1")
+	nextUpdate(t, ts, apis.OrderUpdateKind_UPDATE_ACCEPTED, "b2")
+	o := nextUpdate(t, ts, apis.OrderUpdateKind_UPDATE_REJECTED, "b2")
+	require.Equal(t, apis.OrderLifecycle_ORDER_OPEN, o.Status)
+	require.Equal(t, apis.RejectReason_POST_ONLY_WOULD_CROSS, o.RejectReason)
+	require.Equal(t, int64(9), o.Price)
+
+	m, err := engine.NewMatchingEngine(engine.EngineConfig{PostOnlyReprice: true})
+	require.NoError(t, err)
+	require.NoError(t, m.AddSell(newEvent(t, "s1", events.SellType, &apis.SellRequest{UserId: "user1", Target: "t", Amount: 1, Price: 10})))
+	req.Price = 12
+	require.NoError(t, m.AddBuy(newEvent(t, "b1", events.BuyType, req)))
+	require.Empty(t, m.TakeDeals())
+	require.Equal(t, int64(9), m.Snapshot().Books[0].Bids[0].Price)
+
+	// amends are repriced the same way
+	update = &apis.UpdateRequest{UserId: "user2", RequestId: "b1", Target: "t", Amount: 2, Price: 12}
+	require.NoError(t, m.AddUpdateBuy(newEvent(t, "u1", events.UpdateBuyType, update)))
+	require.Empty(t, m.TakeDeals())
+	bid := m.Snapshot().Books[0].Bids[0]
+	require.Equal(t, int64(9), bid.Price)
+	require.Equal(t, int64(2), bid.Amount)
+}
+
+func testReduceOnly(t *testing.T, ts *testState) {
+	req := &apis.SellRequest{UserId: "user1", Target: "t", Amount: 1, Price: 10, ReduceOnly: true}
+	require.ErrorIs(t, ts.m.AddSell(newEvent(t, "s0", events.SellType, req)), engine.ErrReduceOnlyWouldIncrease)
+
+	// user1 buys 2, so it can reduce by up to 2
+	sell(t, ts, "s1", "user2", 2, 10)
+	buy(t, ts, "b1", "user1", 2, 10)
+	nextDeal(t, ts)
+
+	req.Amount = 3
+	require.ErrorIs(t, ts.m.AddSell(newEvent(t, "s2", events.SellType, req)), engine.ErrReduceOnlyWouldIncrease)
+	req.Amount = 2
+	require.NoError(t, ts.m.AddSell(newEvent(t, "s3", events.SellType, req)))
+
+	// s3 already covers the whole position
+	req.Amount = 1
+	require.ErrorIs(t, ts.m.AddSell(newEvent(t, "s4", events.SellType, req)), engine.ErrReduceOnlyWouldIncrease)
+
+	// shrinking s3 makes room, which an amend cannot take back
+	amend := &apis.UpdateRequest{UserId: "user1", RequestId: "s3", Target: "t", Amount: 1, Price: 10}
+	require.NoError(t, ts.m.AddUpdateSell(newEvent(t, "u1", events.UpdateSellType, amend)))
+	require.NoError(t, ts.m.AddSell(newEvent(t, "s5", events.SellType, req)))
+	amend.Amount = 2
+	require.ErrorIs(t, ts.m.AddUpdateSell(newEvent(t, "u2", events.UpdateSellType, amend)), engine.ErrReduceOnlyWouldIncrease)
+
+	// the refused amend is reported while s3 keeps its state
+	for rejected := false; !rejected; {
+		select {
+		case u := <-ts.updates:
+			if rejected = u.Kind == apis.OrderUpdateKind_UPDATE_REJECTED && u.Order.RequestId == "s3"; rejected {
+				require.Equal(t, apis.OrderLifecycle_ORDER_OPEN, u.Order.Status)
+				require.Equal(t, apis.RejectReason_REDUCE_ONLY_WOULD_INCREASE, u.Order.RejectReason)
+				require.Equal(t, int64(1), u.Order.RemainingAmount)
+			}
+		case <-time.After(time.Second):
+			require.FailNow(t, "the refused amend was not reported")
+		}
+	}
+
+	s := ts.m.Snapshot()
+	require.Equal(t, map[string]int64{"user1": 2, "user2": -2}, s.Books[0].Positions)
+}
+
+func testSelfTradePrevention(t *testing.T, ts *testState) {
+	// user1 rests 2 then user2 rests 1 at the same price; user1 buys 3
+	run := func(t *testing.T, conf engine.EngineConfig, mode apis.SelfTradePrevention) ([]*apis.GetDealStream, []engine.Order) {
+		m, err := engine.NewMatchingEngine(conf)
+		require.NoError(t, err)
+		require.NoError(t, m.AddSell(newEvent(t, "s1", events.SellType, &apis.SellRequest{UserId: "user1", Target: "t", Amount: 2, Price: 10})))
+		require.NoError(t, m.AddSell(newEvent(t, "s2", events.SellType, &apis.SellRequest{UserId: "user2", Target: "t", Amount: 1, Price: 10})))
+		req := &apis.BuyRequest{UserId: "user1", Target: "t", Amount: 3, Price: 10, SelfTradePrevention: mode}
+		require.NoError(t, m.AddBuy(newEvent(t, "b1", events.BuyType, req)))
+		return m.TakeDeals(), m.Snapshot().Books[0].Asks
+	}
+
+	t.Run("cancel newest", func(t *testing.T) {
+		deals, asks := run(t, engine.EngineConfig{}, apis.SelfTradePrevention_CANCEL_NEWEST)
+		require.Len(t, deals, 1)
+		require.Equal(t, int64(3), deals[0].SelfTrade.TakerCancelled)
+		require.Equal(t, "b1", deals[0].SelfTrade.TakerOrderId)
+		require.Equal(t, "s1", deals[0].SelfTrade.MakerOrderId)
+		require.Len(t, asks, 2)
+	})
+
+	t.Run("cancel oldest", func(t *testing.T) {
+		deals, asks := run(t, engine.EngineConfig{}, apis.SelfTradePrevention_CANCEL_OLDEST)
+		require.Len(t, deals, 2)
+		require.Equal(t, int64(2), deals[0].SelfTrade.MakerCancelled)
+		require.Equal(t, int64(0), deals[0].SelfTrade.TakerCancelled)
+		require.Equal(t, "s2", deals[1].SellOrderId)
+		require.Equal(t, int64(1), deals[1].Amount)
+		require.Empty(t, asks)
+	})
+
+	t.Run("cancel both", func(t *testing.T) {
+		deals, asks := run(t, engine.EngineConfig{}, apis.SelfTradePrevention_CANCEL_BOTH)
+		require.Len(t, deals, 1)
+		require.Equal(t, int64(3), deals[0].SelfTrade.TakerCancelled)
+		require.Equal(t, int64(2), deals[0].SelfTrade.MakerCancelled)
+		require.Len(t, asks, 1)
+		require.Equal(t, "s2", asks[0].ID)
+	})
+
+	t.Run("decrement and cancel", func(t *testing.T) {
+		deals, asks := run(t, engine.EngineConfig{}, apis.SelfTradePrevention_DECREMENT_AND_CANCEL)
+		require.Len(t, deals, 2)
+		require.Equal(t, int64(2), deals[0].SelfTrade.TakerCancelled)
+		require.Equal(t, int64(2), deals[0].SelfTrade.MakerCancelled)
+		require.Equal(t, "s2", deals[1].SellOrderId)
+		require.Empty(t, asks)
+	})
+
+	t.Run("fill or kill", func(t *testing.T) {
+		// the order of user1 sits between enough liquidity of others
+		for mode, filled := range map[apis.SelfTradePrevention]bool{
+			apis.SelfTradePrevention_CANCEL_NEWEST:        false,
+			apis.SelfTradePrevention_CANCEL_OLDEST:        true,
+			apis.SelfTradePrevention_CANCEL_BOTH:          false,
+			apis.SelfTradePrevention_DECREMENT_AND_CANCEL: false,
+		} {
+			m, err := engine.NewMatchingEngine(engine.EngineConfig{})
+			require.NoError(t, err)
+			require.NoError(t, m.AddSell(newEvent(t, "s1", events.SellType, &apis.SellRequest{UserId: "user2", Target: "t", Amount: 2, Price: 10})))
+			require.NoError(t, m.AddSell(newEvent(t, "s2", events.SellType, &apis.SellRequest{UserId: "user1", Target: "t", Amount: 1, Price: 10})))
+			require.NoError(t, m.AddSell(newEvent(t, "s3", events.SellType, &apis.SellRequest{UserId: "user3", Target: "t", Amount: 2, Price: 10})))
+			req := &apis.BuyRequest{UserId: "user1", Target: "t", Amount: 3, Price: 10, TimeInForce: apis.TimeInForce_FOK, SelfTradePrevention: mode}
+			require.NoError(t, m.AddBuy(newEvent(t, "b1", events.BuyType, req)))
+
+			var amount int64
+			deals := m.TakeDeals()
+			for _, d := range deals {
+				amount += d.Amount
+			}
+			if !filled {
+				require.Len(t, deals, 1, mode.String())
+				require.Equal(t, int64(3), deals[0].CancelledAmount, mode.String())
+				require.Len(t, m.Snapshot().Books[0].Asks, 3, mode.String())
+				continue
+			}
+			require.Equal(t, int64(3), amount, mode.String())
+		}
+	})
+
+	t.Run("user default", func(t *testing.T) {
+		conf := engine.EngineConfig{SelfTradePrevention: map[string]engine.SelfTradeMode{"user1": engine.CancelNewest}}
+		deals, _ := run(t, conf, apis.SelfTradePrevention_STP_DEFAULT)
+		require.Len(t, deals, 1)
+		require.Equal(t, apis.SelfTradePrevention_CANCEL_NEWEST, deals[0].SelfTrade.Mode)
+
+		// an order can still opt out of its user default
+		deals, _ = run(t, conf, apis.SelfTradePrevention_STP_ALLOW)
+		require.Equal(t, "user1", deals[0].BuyerId)
+		require.Equal(t, "user1", deals[0].SellerId)
+		require.Equal(t, int64(2), deals[0].Amount)
+	})
+}
+
+func testOrderLifecycle(t *testing.T, ts *testState) {
+	sell(t, ts, "s1", "user1", 3, 10)
+	o := nextUpdate(t, ts, apis.OrderUpdateKind_UPDATE_ACCEPTED, "s1")
+	require.Equal(t, apis.OrderLifecycle_ORDER_OPEN, o.Status)
+	require.Equal(t, apis.Side_SELL, o.Side)
+	require.Equal(t, int64(3), o.RemainingAmount)
+	require.Equal(t, uint64(1), o.Revision)
+
+	// the taker is accepted before it trades, then the maker and the taker
+	// report their fills
+	buy(t, ts, "b1", "user2", 1, 10)
+	o = nextUpdate(t, ts, apis.OrderUpdateKind_UPDATE_ACCEPTED, "b1")
+	require.Equal(t, apis.OrderLifecycle_ORDER_OPEN, o.Status)
+	o = nextUpdate(t, ts, apis.OrderUpdateKind_UPDATE_FILLED, "s1")
+	require.Equal(t, apis.OrderLifecycle_ORDER_PARTIALLY_FILLED, o.Status)
+	require.Equal(t, int64(1), o.FilledAmount)
+	require.Equal(t, int64(2), o.RemainingAmount)
+	require.Equal(t, uint64(2), o.Revision)
+	o = nextUpdate(t, ts, apis.OrderUpdateKind_UPDATE_FILLED, "b1")
+	require.Equal(t, apis.OrderLifecycle_ORDER_FILLED, o.Status)
+
+	req := &apis.BuyRequest{UserId: "user2", Target: "t", Price: 10}
+	require.ErrorIs(t, ts.m.AddBuy(newEvent(t, "b2", events.BuyType, req)), engine.ErrInvalidOrder)
+	o = nextUpdate(t, ts, apis.OrderUpdateKind_UPDATE_REJECTED, "b2")
+	require.Equal(t, apis.OrderLifecycle_ORDER_REJECTED, o.Status)
+	require.Equal(t, apis.RejectReason_INVALID_ORDER, o.RejectReason)
+
+	// a duplicate does not overwrite the state of the first order
+	dup := &apis.SellRequest{UserId: "user1", Target: "t", Amount: 1, Price: 10}
+	require.ErrorIs(t, ts.m.AddSell(newEvent(t, "s1", events.SellType, dup)), engine.ErrDuplicateOrder)
+
+	stop(t, ts, "st1", "user3", engine.Buy, 1, 20, 0)
+	o = nextUpdate(t, ts, apis.OrderUpdateKind_UPDATE_ACCEPTED, "st1")
+	require.Equal(t, apis.OrderLifecycle_ORDER_WAITING, o.Status)
+
+	update := &apis.UpdateRequest{UserId: "user1", RequestId: "s1", Target: "t", Amount: 2, Price: 10}
+	require.NoError(t, ts.m.AddUpdateSell(newEvent(t, "u1", events.UpdateSellType, update)))
+	o = nextUpdate(t, ts, apis.OrderUpdateKind_UPDATE_AMENDED, "s1")
+	require.Equal(t, int64(2), o.RemainingAmount)
+
+	cancel := &apis.CancelRequest{UserId: "user1", RequestId: "s1"}
+	require.NoError(t, ts.m.AddCancel(newEvent(t, "c1", events.CancelType, cancel)))
+	o = nextUpdate(t, ts, apis.OrderUpdateKind_UPDATE_CANCELLED, "s1")
+	require.Equal(t, apis.OrderLifecycle_ORDER_CANCELLED, o.Status)
+	require.Equal(t, int64(3), o.Amount)
+	require.Equal(t, int64(1), o.FilledAmount)
+	require.Equal(t, int64(2), o.CancelledAmount)
+	require.Equal(t, int64(0), o.RemainingAmount)
+
+	// a GTD order is reported as expired once the clock passes its expiry
+	at := time.Now().Add(time.Hour)
+	gtd := &apis.BuyRequest{UserId: "user2", Target: "t", Amount: 1, Price: 5, TimeInForce: apis.TimeInForce_GTD, ExpireAt: at.UnixMilli()}
+	require.NoError(t, ts.m.AddBuy(newEvent(t, "b3", events.BuyType, gtd)))
+	nextUpdate(t, ts, apis.OrderUpdateKind_UPDATE_ACCEPTED, "b3")
+	require.NoError(t, ts.m.AddExpire(timedEvent(t, "x1", events.ExpireType, at, nil)))
+	o = nextUpdate(t, ts, apis.OrderUpdateKind_UPDATE_EXPIRED, "b3")
+	require.Equal(t, apis.OrderLifecycle_ORDER_CANCELLED, o.Status)
+}
+
+func nextDepth(t *testing.T, ts *testState) *apis.DepthUpdate {
+	t.Helper()
+
+	select {
+	case u := <-ts.depth:
+		return u
+	case <-time.After(time.Second):
+		require.FailNow(t, "depth update was not handed over")
+		return nil
+	}
+}
+
+func testDepth(t *testing.T, ts *testState) {
+	type level struct{ price, amount, orders int64 }
+	levels := func(list []*apis.PriceLevel) []level {
+		var out []level
+		for _, l := range list {
+			out = append(out, level{l.Price, l.Amount, l.OrderCount})
+		}
+		return out
+	}
+
+	buy(t, ts, "b1", "user1", 2, 9)
+	buy(t, ts, "b2", "user2", 1, 9)
+	sell(t, ts, "s1", "user3", 4, 11)
+	// icebergs only show their displayed slice
+	iceberg := &apis.SellRequest{UserId: "user4", Target: "t", Amount: 10, Price: 12, DisplayAmount: 2}
+	require.NoError(t, ts.m.AddSell(newEvent(t, "s2", events.SellType, iceberg)))
+
+	d := ts.m.Depth("t", 0)
+	require.Equal(t, uint64(4), d.Seq)
+	require.Equal(t, []level{{9, 3, 2}}, levels(d.Bids))
+	require.Equal(t, []level{{11, 4, 1}, {12, 2, 1}}, levels(d.Asks))
+	require.Len(t, ts.m.Depth("t", 1).Asks, 1)
+	require.Empty(t, ts.m.Depth("unknown", 0).Bids)
+
+	for seq := uint64(1); seq <= 4; seq++ {
+		require.Equal(t, seq, nextDepth(t, ts).Seq)
+	}
+
+	// one update per event holds every level it changed, an emptied level
+	// has amount 0
+	req := &apis.BuyRequest{UserId: "user5", Target: "t", Amount: 5, Price: 12}
+	require.NoError(t, ts.m.AddBuy(newEvent(t, "b3", events.BuyType, req)))
+	u := nextDepth(t, ts)
+	require.Equal(t, uint64(5), u.Seq)
+	require.False(t, u.Snapshot)
+	require.Empty(t, u.Bids)
+	require.Equal(t, []level{{11, 0, 0}, {12, 1, 1}}, levels(u.Asks))
+
+	// rejected events change nothing and do not take a sequence
+	invalid := &apis.BuyRequest{UserId: "user5", Target: "t", Price: 12}
+	require.ErrorIs(t, ts.m.AddBuy(newEvent(t, "b4", events.BuyType, invalid)), engine.ErrInvalidOrder)
+	cancel := &apis.CancelRequest{UserId: "user1", RequestId: "b1"}
+	require.NoError(t, ts.m.AddCancel(newEvent(t, "c1", events.CancelType, cancel)))
+	u = nextDepth(t, ts)
+	require.Equal(t, uint64(6), u.Seq)
+	require.Equal(t, []level{{9, 1, 1}}, levels(u.Bids))
+}
+
+func testL3Feed(t *testing.T, ts *testState) {
+	type message struct {
+		action   apis.L3Action
+		order    string
+		amount   int64
+		executed int64
+	}
+	var seq uint64
+	// handles are learnt from the first message of each order
+	handles := make(map[string]uint64)
+	expect := func(journalSeq uint64, messages ...message) {
+		t.Helper()
+		for _, ex := range messages {
+			seq++
+			select {
+			case msg := <-ts.l3:
+				require.Equal(t, seq, msg.Seq)
+				require.Equal(t, journalSeq, msg.JournalSeq)
+				handle, ok := handles[ex.order]
+				if !ok {
+					require.NotZero(t, msg.OrderHandle)
+					for _, other := range handles {
+						require.NotEqual(t, other, msg.OrderHandle)
+					}
+					handle = msg.OrderHandle
+					handles[ex.order] = handle
+				}
+				require.Equal(t, ex, message{msg.Action, ex.order, msg.Amount, msg.ExecutedAmount})
+				require.Equal(t, handle, msg.OrderHandle)
+				if ex.action == apis.L3Action_L3_EXECUTE {
+					require.NotEmpty(t, msg.DealId)
+				}
+			case <-time.After(time.Second):
+				require.FailNow(t, "l3 message was not handed over")
+			}
+		}
+	}
+	apply := func(journalSeq uint64, e cloudevents.Event) {
+		t.Helper()
+		events.SetSequence(&e, journalSeq)
+		require.NoError(t, engine.Apply(ts.m, e))
+	}
+
+	apply(1, newEvent(t, "s1", events.SellType, &apis.SellRequest{UserId: "user1", Target: "t", Amount: 2, Price: 10}))
+	expect(1, message{apis.L3Action_L3_ADD, "s1", 2, 0})
+	iceberg := &apis.SellRequest{UserId: "user2", Target: "t", Amount: 5, Price: 10, DisplayAmount: 2}
+	apply(2, newEvent(t, "s2", events.SellType, iceberg))
+	expect(2, message{apis.L3Action_L3_ADD, "s2", 2, 0})
+
+	// the iceberg slice is used up and joins the back of the level again,
+	// leaving and entering the book so that it loses its priority
+	apply(3, newEvent(t, "b1", events.BuyType, &apis.BuyRequest{UserId: "user3", Target: "t", Amount: 4, Price: 10}))
+	expect(3,
+		message{apis.L3Action_L3_EXECUTE, "s1", 0, 2},
+		message{apis.L3Action_L3_EXECUTE, "s2", 0, 2},
+		message{apis.L3Action_L3_DELETE, "s2", 0, 0},
+		message{apis.L3Action_L3_ADD, "s2", 2, 0},
+	)
+
+	amend := &apis.UpdateRequest{UserId: "user2", RequestId: "s2", Target: "t", Amount: 1, Price: 10}
+	apply(4, newEvent(t, "u1", events.UpdateSellType, amend))
+	expect(4, message{apis.L3Action_L3_MODIFY, "s2", 1, 0})
+
+	// repricing keeps the handle
+	amend = &apis.UpdateRequest{UserId: "user2", RequestId: "s2", Target: "t", Amount: 1, Price: 11}
+	apply(5, newEvent(t, "u2", events.UpdateSellType, amend))
+	expect(5, message{apis.L3Action_L3_DELETE, "s2", 0, 0}, message{apis.L3Action_L3_ADD, "s2", 1, 0})
+
+	apply(6, newEvent(t, "c1", events.CancelType, &apis.CancelRequest{UserId: "user2", RequestId: "s2"}))
+	expect(6, message{apis.L3Action_L3_DELETE, "s2", 0, 0})
+}
+
+func TestL3Handle(t *testing.T) {
+	handle := func(key string) uint64 {
+		t.Helper()
+		m, err := engine.NewMatchingEngine(engine.EngineConfig{HandleKey: key})
+		require.NoError(t, err)
+		require.NoError(t, m.AddSell(newEvent(t, "s1", events.SellType, &apis.SellRequest{UserId: "user1", Target: "t", Amount: 1, Price: 10})))
+		l3 := m.TakeL3()
+		require.Len(t, l3, 1)
+		return l3[0].OrderHandle
+	}
+
+	// the same key gives the same handles, so a replay publishes them again
+	require.Equal(t, handle("key"), handle("key"))
+	require.NotEqual(t, handle("key"), handle("other"))
+	require.NotEqual(t, handle(""), handle(""))
+}
+
+func testFees(t *testing.T, ts *testState) {
+	// trades without a schedule are free
+	sell(t, ts, "s0", "user1", 1, 10)
+	buy(t, ts, "b0", "user2", 1, 10)
+	d := nextDeal(t, ts)
+	require.Zero(t, d.BuyerFee)
+	require.Zero(t, d.SellerFee)
+
+	conf := engine.EngineConfig{Fees: engine.FeeConfig{
+		Default: engine.FeeSchedule{MakerBps: 10, TakerBps: 20, Tiers: []engine.FeeTier{{Volume: 4000, MakerBps: 0, TakerBps: 10}}},
+		Targets: map[string]engine.FeeSchedule{"v": {TakerBps: 5}},
+	}}
+	m, err := engine.NewMatchingEngine(conf)
+	require.NoError(t, err)
+	order := func(id, typ, user, target string, amount, price int64) {
+		t.Helper()
+		if typ == events.BuyType {
+			require.NoError(t, m.AddBuy(newEvent(t, id, typ, &apis.BuyRequest{UserId: user, Target: target, Amount: amount, Price: price})))
+		} else {
+			require.NoError(t, m.AddSell(newEvent(t, id, typ, &apis.SellRequest{UserId: user, Target: target, Amount: amount, Price: price})))
+		}
+	}
+
+	// the taker pays 20 bps and the maker 10, rounded up
+	order("s1", events.SellType, "user1", "t", 10, 1000)
+	order("b1", events.BuyType, "user2", "t", 4, 1000)
+	deals := m.TakeDeals()
+	require.Len(t, deals, 1)
+	require.Equal(t, int64(8), deals[0].BuyerFee)
+	require.Equal(t, int64(4), deals[0].SellerFee)
+
+	// both reached the first tier with 4000 traded, the seller is taker now
+	order("b2", events.BuyType, "user1", "t", 1, 999)
+	order("s2", events.SellType, "user2", "t", 1, 999)
+	deals = m.TakeDeals()
+	require.Equal(t, int64(0), deals[0].BuyerFee)
+	require.Equal(t, int64(1), deals[0].SellerFee)
+
+	order("b3", events.BuyType, "user2", "t", 1, 1000)
+	deals = m.TakeDeals()
+	require.Equal(t, int64(1), deals[0].BuyerFee)
+	require.Equal(t, int64(0), deals[0].SellerFee)
+
+	// tiers survive a restart
+	s := m.Snapshot()
+	require.Equal(t, map[string]int64{"user1": 5999, "user2": 5999}, s.Books[0].Volumes)
+	restored, err := engine.NewMatchingEngine(conf)
+	require.NoError(t, err)
+	require.NoError(t, restored.Restore(s))
+	require.NoError(t, restored.AddBuy(newEvent(t, "b4", events.BuyType, &apis.BuyRequest{UserId: "user2", Target: "t", Amount: 1, Price: 1000})))
+	require.Equal(t, int64(1), restored.TakeDeals()[0].BuyerFee)
+
+	order("s5", events.SellType, "user1", "v", 1, 1000)
+	order("b5", events.BuyType, "user2", "v", 1, 1000)
+	deals = m.TakeDeals()
+	require.Equal(t, int64(1), deals[0].BuyerFee)
+	require.Equal(t, int64(0), deals[0].SellerFee)
+
+	conf.Fees.Default.Tiers = append(conf.Fees.Default.Tiers, engine.FeeTier{Volume: 10, TakerBps: 1})
+	_, err = engine.NewMatchingEngine(conf)
+	require.Error(t, err)
+}
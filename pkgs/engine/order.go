@@ -0,0 +1,170 @@
+package engine
+
+import "github.com/atgane/opentd/apis"
+
+type Side int
+
+const (
+	Buy Side = iota
+	Sell
+)
+
+func (s Side) String() string {
+	if s == Buy {
+		return "buy"
+	}
+	return "sell"
+}
+
+type OrderType int
+
+const (
+	Limit OrderType = iota
+	// Market orders sweep the opposite side up to their protection price and
+	// never rest; whatever is left is cancelled.
+	Market
+	// Stop and StopLimit orders wait off the book until the last trade price
+	// reaches StopPrice and then enter as Market and Limit orders.
+	Stop
+	StopLimit
+)
+
+func (t OrderType) String() string {
+	switch t {
+	case Market:
+		return "market"
+	case Stop:
+		return "stop"
+	case StopLimit:
+		return "stop_limit"
+	}
+	return "limit"
+}
+
+type TimeInForce int
+
+const (
+	GTC TimeInForce = iota
+	IOC
+	FOK
+	GTD
+	DAY
+)
+
+// rests reports whether an unfilled remainder may stay on the book.
+func (t TimeInForce) rests() bool {
+	return t == GTC || t == GTD || t == DAY
+}
+
+type SelfTradeMode int
+
+const (
+	// SelfTradeDefault falls back to the mode configured for the user.
+	SelfTradeDefault SelfTradeMode = iota
+	SelfTradeAllow
+	CancelNewest
+	CancelOldest
+	CancelBoth
+	DecrementAndCancel
+)
+
+// prevents reports whether the mode stops orders of one user from trading.
+func (s SelfTradeMode) prevents() bool {
+	return s > SelfTradeAllow
+}
+
+// cancelsTaker reports whether meeting an order of the same user takes from
+// the taker, which then cannot be filled completely.
+func (s SelfTradeMode) cancelsTaker() bool {
+	return s == CancelNewest || s == CancelBoth || s == DecrementAndCancel
+}
+
+type Order struct {
+	ID          string      `json:"id"`
+	UserID      string      `json:"user_id"`
+	Target      string      `json:"target"`
+	Side        Side        `json:"side"`
+	Type        OrderType   `json:"type"`
+	Price       int64       `json:"price"`
+	Amount      int64       `json:"amount"`
+	Seq         uint64      `json:"seq"`
+	TimeInForce TimeInForce `json:"time_in_force"`
+	// ExpireAt is the unix millisecond expiry of GTD and DAY orders.
+	ExpireAt  int64 `json:"expire_at,omitempty"`
+	StopPrice int64 `json:"stop_price,omitempty"`
+	// ProtectionPrice and MaxSlippage are kept for stop orders, which turn
+	// into market orders only when they trigger.
+	ProtectionPrice int64 `json:"protection_price,omitempty"`
+	MaxSlippage     int64 `json:"max_slippage,omitempty"`
+	// DisplayAmount makes an iceberg order: only Visible, at most
+	// DisplayAmount, of Amount is shown and can be hit at a time.
+	DisplayAmount int64 `json:"display_amount,omitempty"`
+	Visible       int64 `json:"visible,omitempty"`
+	PostOnly      bool  `json:"post_only,omitempty"`
+	// ReduceOnly is checked on entry and when an amend grows the order,
+	// against the position at that time less the other open reduce only
+	// orders of the user.
+	ReduceOnly bool          `json:"reduce_only,omitempty"`
+	SelfTrade  SelfTradeMode `json:"self_trade,omitempty"`
+	// Filled and Cancelled add up with Amount to the ordered amount.
+	Filled     int64  `json:"filled,omitempty"`
+	Cancelled  int64  `json:"cancelled,omitempty"`
+	CreatedSeq uint64 `json:"created_seq,omitempty"`
+	CreatedAt  int64  `json:"created_at,omitempty"`
+	Revision   uint64 `json:"revision,omitempty"`
+	// Handle anonymously identifies the order in the L3 feed. It is derived
+	// from the ID when the order first rests, see EngineConfig.HandleKey.
+	Handle uint64 `json:"handle,omitempty"`
+}
+
+// state describes o for the order state store. at is the engine clock.
+func (o *Order) state(reason apis.RejectReason, at int64) *apis.Order {
+	s := new(apis.Order)
+	s.RequestId = o.ID
+	s.UserId = o.UserID
+	s.Target = o.Target
+	s.Side = apis.Side(o.Side)
+	s.OrderType = apis.OrderType(o.Type)
+	s.Price = o.Price
+	s.StopPrice = o.StopPrice
+	s.Amount = o.Filled + o.Cancelled + o.Amount
+	s.FilledAmount = o.Filled
+	s.CancelledAmount = o.Cancelled
+	s.RemainingAmount = o.Amount
+	s.RejectReason = reason
+	s.Seq = o.CreatedSeq
+	s.CreatedAt = o.CreatedAt
+	s.UpdatedAt = at
+	s.Revision = o.Revision
+
+	switch {
+	case reason != apis.RejectReason_NO_REASON:
+		s.Status = apis.OrderLifecycle_ORDER_REJECTED
+	case o.Amount == 0 && o.Cancelled > 0:
+		s.Status = apis.OrderLifecycle_ORDER_CANCELLED
+	case o.Amount == 0:
+		s.Status = apis.OrderLifecycle_ORDER_FILLED
+	case o.Type == Stop || o.Type == StopLimit:
+		s.Status = apis.OrderLifecycle_ORDER_WAITING
+	case o.Filled > 0:
+		s.Status = apis.OrderLifecycle_ORDER_PARTIALLY_FILLED
+	default:
+		s.Status = apis.OrderLifecycle_ORDER_OPEN
+	}
+	return s
+}
+
+// shown returns the part of o that is visible on the book.
+func (o *Order) shown() int64 {
+	if o.DisplayAmount > 0 {
+		return o.Visible
+	}
+	return o.Amount
+}
+
+// reveal shows the next slice of an iceberg order.
+func (o *Order) reveal() {
+	if o.DisplayAmount > 0 {
+		o.Visible = min(o.DisplayAmount, o.Amount)
+	}
+}
@@ -0,0 +1,173 @@
+package engine
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+
+	"github.com/atgane/opentd/apis"
+)
+
+const SnapshotVersion = 1
+
+// Snapshot is the serializable state of a MatchingEngine. Orders of each book
+// are listed in priority order so restoring them one by one rebuilds the same
+// queues. PendingDeals and PendingUpdates had not been handed to Start's
+// callbacks yet when the snapshot was taken; they are handed again after Restore.
+// Depth updates and L3 messages are not kept, market data starts over from the
+// book after a restart.
+type Snapshot struct {
+	Version        int                   `json:"version"`
+	Seq            uint64                `json:"seq"`
+	LastEventID    string                `json:"last_event_id"`
+	JournalSeq     uint64                `json:"journal_seq"`
+	Clock          int64                 `json:"clock,omitempty"`
+	Books          []BookSnapshot        `json:"books"`
+	PendingDeals   []*apis.GetDealStream `json:"pending_deals,omitempty"`
+	PendingUpdates []*apis.OrderUpdate   `json:"pending_updates,omitempty"`
+}
+
+type BookSnapshot struct {
+	Target    string  `json:"target"`
+	DealSeq   uint64  `json:"deal_seq"`
+	LastPrice int64   `json:"last_price,omitempty"`
+	Bids      []Order `json:"bids"`
+	Asks      []Order `json:"asks"`
+	// Stops are waiting stop orders, buys then sells, each in trigger order.
+	Stops     []Order          `json:"stops,omitempty"`
+	Positions map[string]int64 `json:"positions,omitempty"`
+	Volumes   map[string]int64 `json:"volumes,omitempty"`
+	DepthSeq  uint64           `json:"depth_seq,omitempty"`
+	FeedSeq   uint64           `json:"feed_seq,omitempty"`
+}
+
+func (m *MatchingEngine) Snapshot() *Snapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := new(Snapshot)
+	s.Version = SnapshotVersion
+	s.Seq = m.seq
+	s.LastEventID = m.lastEventID
+	s.JournalSeq = m.journalSeq
+	s.Clock = m.clock
+	s.PendingDeals = append(s.PendingDeals, m.pending...)
+	s.PendingUpdates = append(s.PendingUpdates, m.updates...)
+
+	targets := make([]string, 0, len(m.books))
+	for target := range m.books {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	for _, target := range targets {
+		b := m.books[target]
+		s.Books = append(s.Books, BookSnapshot{
+			Target:    target,
+			DealSeq:   b.dealSeq,
+			LastPrice: b.lastPrice,
+			Bids:      flatten(b.bids),
+			Asks:      flatten(b.asks),
+			Stops:     b.stops.orders(),
+			Positions: positions(b.positions),
+			Volumes:   positions(b.volumes),
+			DepthSeq:  b.depthSeq,
+			FeedSeq:   b.feedSeq,
+		})
+	}
+	return s
+}
+
+// Restore replaces the engine state with s. It is meant to be called before
+// any event is applied.
+func (m *MatchingEngine) Restore(s *Snapshot) error {
+	if s.Version != SnapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d", s.Version)
+	}
+
+	books := make(map[string]*orderBook, len(s.Books))
+	orders := make(map[string]*Order)
+	var expiries expiryQueue
+	for _, bs := range s.Books {
+		b := newOrderBook(bs.Target, m.fees.schedule(bs.Target), m.handleKey)
+		b.dealSeq = bs.DealSeq
+		b.lastPrice = bs.LastPrice
+		for user, pos := range bs.Positions {
+			b.positions[user] = pos
+		}
+		for user, volume := range bs.Volumes {
+			b.volumes[user] = volume
+		}
+		for side, list := range map[Side][]Order{Buy: bs.Bids, Sell: bs.Asks} {
+			for i := range list {
+				o := list[i]
+				if _, ok := orders[o.ID]; ok || o.Target != bs.Target || o.Side != side || o.Type != Limit || !o.TimeInForce.rests() ||
+					(o.DisplayAmount > 0 && (o.Visible <= 0 || o.Visible > min(o.DisplayAmount, o.Amount))) {
+					return fmt.Errorf("corrupted snapshot at order %s", o.ID)
+				}
+				b.rest(&o)
+				orders[o.ID] = &o
+				if o.ExpireAt > 0 {
+					expiries = append(expiries, expiryEntry{at: o.ExpireAt, seq: o.Seq, o: &o})
+				}
+			}
+		}
+		for i := range bs.Stops {
+			o := bs.Stops[i]
+			if _, ok := orders[o.ID]; ok || o.Target != bs.Target || (o.Type != Stop && o.Type != StopLimit) {
+				return fmt.Errorf("corrupted snapshot at order %s", o.ID)
+			}
+			b.stops.add(&o)
+			orders[o.ID] = &o
+			if o.ExpireAt > 0 {
+				expiries = append(expiries, expiryEntry{at: o.ExpireAt, seq: o.Seq, o: &o})
+			}
+		}
+		b.depthSeq = bs.DepthSeq
+		b.feedSeq = bs.FeedSeq
+		b.dirty = nil
+		b.l3 = nil
+		books[bs.Target] = b
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.books = books
+	m.orders = orders
+	m.seq = s.Seq
+	m.lastEventID = s.LastEventID
+	m.journalSeq = s.JournalSeq
+	m.clock = s.Clock
+	heap.Init(&expiries)
+	m.expiries = expiries
+	m.pending = nil
+	m.publish(s.PendingDeals)
+	m.updates = append([]*apis.OrderUpdate(nil), s.PendingUpdates...)
+	m.depth = nil
+	m.l3 = nil
+	m.wake()
+	return nil
+}
+
+func positions(src map[string]int64) map[string]int64 {
+	if len(src) == 0 {
+		return nil
+	}
+
+	dst := make(map[string]int64, len(src))
+	for user, pos := range src {
+		dst[user] = pos
+	}
+	return dst
+}
+
+func flatten(levels []*priceLevel) []Order {
+	var orders []Order
+	for _, level := range levels {
+		for _, o := range level.orders {
+			orders = append(orders, *o)
+		}
+	}
+	return orders
+}
@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"sort"
+)
+
+// stopBook holds the stop orders of one target. Buy stops fire from the lowest
+// stop price up and sell stops from the highest down, so each side is kept in
+// the order its stops would be reached by a moving price, arrival order
+// breaking ties.
+type stopBook struct {
+	buys  []*Order
+	sells []*Order
+}
+
+func (s *stopBook) side(side Side) *[]*Order {
+	if side == Buy {
+		return &s.buys
+	}
+	return &s.sells
+}
+
+// fires reports whether a of side s fires before b.
+func fires(s Side, a, b *Order) bool {
+	if a.StopPrice != b.StopPrice {
+		return better(s, b.StopPrice, a.StopPrice)
+	}
+	return a.Seq < b.Seq
+}
+
+func (s *stopBook) add(o *Order) {
+	list := s.side(o.Side)
+	idx := sort.Search(len(*list), func(i int) bool {
+		return fires(o.Side, o, (*list)[i])
+	})
+
+	*list = append(*list, nil)
+	copy((*list)[idx+1:], (*list)[idx:])
+	(*list)[idx] = o
+}
+
+func (s *stopBook) remove(o *Order) bool {
+	list := s.side(o.Side)
+	for i := range *list {
+		if (*list)[i] == o {
+			*list = append((*list)[:i], (*list)[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// next removes and returns the stop that fires first at last, or nil when no
+// stop is reached. When a buy and a sell stop are both reached the earlier
+// arrival goes first.
+func (s *stopBook) next(last int64) *Order {
+	if last <= 0 {
+		return nil
+	}
+
+	var buy, sell *Order
+	if len(s.buys) > 0 && s.buys[0].StopPrice <= last {
+		buy = s.buys[0]
+	}
+	if len(s.sells) > 0 && s.sells[0].StopPrice >= last {
+		sell = s.sells[0]
+	}
+
+	o := buy
+	if o == nil || (sell != nil && sell.Seq < buy.Seq) {
+		o = sell
+	}
+	if o != nil {
+		s.remove(o)
+	}
+	return o
+}
+
+func (s *stopBook) orders() []Order {
+	var orders []Order
+	for _, list := range [][]*Order{s.buys, s.sells} {
+		for _, o := range list {
+			orders = append(orders, *o)
+		}
+	}
+	return orders
+}
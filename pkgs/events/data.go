@@ -0,0 +1,61 @@
+package events
+
+import (
+	"fmt"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"google.golang.org/protobuf/proto"
+)
+
+// ApplicationProtobuf encodes event data with proto.Marshal. The dataschema
+// attribute then names the protobuf message, e.g. "proto:BuyRequest"; the
+// scheme is there because cloudevents requires dataschema to be an absolute
+// URI.
+const ApplicationProtobuf = "application/protobuf"
+
+const protobufSchemaScheme = "proto:"
+
+func protobufSchema(m proto.Message) string {
+	return protobufSchemaScheme + string(m.ProtoReflect().Descriptor().FullName())
+}
+
+// CheckContentType returns an error for a content type SetData cannot encode,
+// so services refuse it when they start rather than on every event.
+func CheckContentType(contentType string) error {
+	switch contentType {
+	case "", cloudevents.ApplicationJSON, ApplicationProtobuf:
+		return nil
+	}
+	return fmt.Errorf("unsupported data content type %s", contentType)
+}
+
+// SetData encodes m with the given content type. An empty content type keeps
+// the json encoding, so events stay readable by consumers that predate the
+// protobuf encoding.
+func SetData(e *cloudevents.Event, contentType string, m proto.Message) error {
+	switch contentType {
+	case "", cloudevents.ApplicationJSON:
+		return e.SetData(cloudevents.ApplicationJSON, m)
+	case ApplicationProtobuf:
+		b, err := proto.Marshal(m)
+		if err != nil {
+			return err
+		}
+		e.SetDataSchema(protobufSchema(m))
+		return e.SetData(ApplicationProtobuf, b)
+	}
+	return CheckContentType(contentType)
+}
+
+// DataAs decodes the event data into m based on the event DataContentType,
+// so json and protobuf producers can run side by side during a migration.
+func DataAs(e cloudevents.Event, m proto.Message) error {
+	if e.DataContentType() != ApplicationProtobuf {
+		return e.DataAs(m)
+	}
+
+	if schema := e.DataSchema(); schema != "" && schema != protobufSchema(m) {
+		return fmt.Errorf("event data schema %s does not match %s", schema, protobufSchema(m))
+	}
+	return proto.Unmarshal(e.Data(), m)
+}
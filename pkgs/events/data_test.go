@@ -0,0 +1,52 @@
+package events_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/atgane/opentd/apis"
+	"github.com/atgane/opentd/pkgs/events"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDataEncodings(t *testing.T) {
+	req := &apis.BuyRequest{UserId: "u1", Target: "t", Amount: 3, Price: 10}
+
+	for _, contentType := range []string{"", cloudevents.ApplicationJSON, events.ApplicationProtobuf} {
+		require.NoError(t, events.CheckContentType(contentType))
+
+		e := cloudevents.NewEvent()
+		e.SetID("e1")
+		e.SetType(events.BuyType)
+		e.SetSource(events.FrontendSource)
+		require.NoError(t, events.SetData(&e, contentType, req))
+
+		// events cross journals and structured transports as json documents
+		b, err := json.Marshal(e)
+		require.NoError(t, err)
+		var decoded cloudevents.Event
+		require.NoError(t, json.Unmarshal(b, &decoded))
+
+		got := new(apis.BuyRequest)
+		require.NoError(t, events.DataAs(decoded, got))
+		require.True(t, proto.Equal(req, got), "content type %q", contentType)
+	}
+
+	e := cloudevents.NewEvent()
+	require.Error(t, events.CheckContentType("text/xml"))
+	require.Error(t, events.SetData(&e, "text/xml", req))
+}
+
+func TestDataSchemaMismatch(t *testing.T) {
+	e := cloudevents.NewEvent()
+	e.SetID("e1")
+	e.SetType(events.BuyType)
+	e.SetSource(events.FrontendSource)
+	require.NoError(t, events.SetData(&e, events.ApplicationProtobuf, &apis.BuyRequest{UserId: "u1"}))
+	require.Equal(t, "proto:BuyRequest", e.DataSchema())
+	require.NoError(t, e.Validate())
+
+	require.Error(t, events.DataAs(e, new(apis.CancelRequest)))
+}
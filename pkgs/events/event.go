@@ -4,15 +4,49 @@ import (
 	"fmt"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/client"
 )
 
 const (
-	NATS = "nats"
+	NATS          = "nats"
+	JETSTREAM     = "jetstream"
+	KAFKA         = "kafka"
+	REDIS_STREAMS = "redis_streams"
+	INMEMORY      = "inmemory"
 )
 
+// orderedReceive makes consumer clients hand events to the receiver one at a
+// time in delivery order, which the matching engine relies on.
+var orderedReceive = []client.Option{
+	client.WithPollGoroutines(1),
+	client.WithBlockingCallback(),
+}
+
 type EventConfig struct {
-	EventType  string
-	NATSConfig NATSConfig
+	EventType          string
+	NATSConfig         NATSConfig
+	JetStreamConfig    JetStreamConfig
+	KafkaConfig        KafkaConfig
+	RedisStreamsConfig RedisStreamsConfig
+	InMemoryConfig     InMemoryConfig
+}
+
+// Destination returns the subject, topic or stream conf publishes to and
+// consumes from.
+func (conf EventConfig) Destination() string {
+	switch conf.EventType {
+	case NATS:
+		return conf.NATSConfig.Subject
+	case JETSTREAM:
+		return conf.JetStreamConfig.Subject
+	case KAFKA:
+		return conf.KafkaConfig.Topic
+	case REDIS_STREAMS:
+		return conf.RedisStreamsConfig.Stream
+	case INMEMORY:
+		return conf.InMemoryConfig.Topic
+	}
+	return ""
 }
 
 func NewConsumerEvent(conf EventConfig) (c cloudevents.Client, err error) {
@@ -22,6 +56,30 @@ func NewConsumerEvent(conf EventConfig) (c cloudevents.Client, err error) {
 		}
 		return c, nil
 	}
+	if conf.EventType == JETSTREAM {
+		if c, err = newJetStreamConsumerEventClient(conf.JetStreamConfig); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	if conf.EventType == KAFKA {
+		if c, err = newKafkaConsumerEventClient(conf.KafkaConfig); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	if conf.EventType == REDIS_STREAMS {
+		if c, err = newRedisStreamsConsumerEventClient(conf.RedisStreamsConfig); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	if conf.EventType == INMEMORY {
+		if c, err = newInMemoryConsumerEventClient(conf.InMemoryConfig); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
 
 	return nil, fmt.Errorf("undefined event")
 }
@@ -33,6 +91,30 @@ func NewProducerEvent(conf EventConfig) (c cloudevents.Client, err error) {
 		}
 		return c, nil
 	}
+	if conf.EventType == JETSTREAM {
+		if c, err = newJetStreamProducerEventClient(conf.JetStreamConfig); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	if conf.EventType == KAFKA {
+		if c, err = newKafkaProducerEventClient(conf.KafkaConfig); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	if conf.EventType == REDIS_STREAMS {
+		if c, err = newRedisStreamsProducerEventClient(conf.RedisStreamsConfig); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
+	if conf.EventType == INMEMORY {
+		if c, err = newInMemoryProducerEventClient(conf.InMemoryConfig); err != nil {
+			return nil, err
+		}
+		return c, nil
+	}
 
 	return nil, fmt.Errorf("undefined event")
 }
@@ -0,0 +1,176 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+)
+
+type InMemoryConfig struct {
+	Topic string
+	// BufferSize is the number of events a consumer can lag behind before
+	// producers block.
+	BufferSize int
+}
+
+// inMemoryTopics connects producers and consumers of the same process by
+// topic name. Every consumer created for a topic receives every event sent to
+// it after the consumer was created, like a core NATS subject, until its
+// receiver returns.
+var inMemoryTopics = struct {
+	mu     sync.Mutex
+	topics map[string]*inMemoryTopic
+}{topics: make(map[string]*inMemoryTopic)}
+
+type inMemoryTopic struct {
+	mu   sync.RWMutex
+	subs []*inMemorySub
+}
+
+// inMemorySub is a subscription of a consumer. gone is closed when it is
+// removed, so a producer blocked on its full buffer moves on.
+type inMemorySub struct {
+	ch   chan cloudevents.Event
+	gone chan struct{}
+}
+
+func (t *inMemoryTopic) subscribe(ch chan cloudevents.Event) *inMemorySub {
+	sub := &inMemorySub{ch: ch, gone: make(chan struct{})}
+	t.mu.Lock()
+	t.subs = append(t.subs, sub)
+	t.mu.Unlock()
+	return sub
+}
+
+func (t *inMemoryTopic) unsubscribe(sub *inMemorySub) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, s := range t.subs {
+		if s == sub {
+			t.subs = append(t.subs[:i], t.subs[i+1:]...)
+			close(sub.gone)
+			return
+		}
+	}
+}
+
+func getInMemoryTopic(name string) (*inMemoryTopic, error) {
+	if name == "" {
+		return nil, fmt.Errorf("in-memory topic is required")
+	}
+
+	inMemoryTopics.mu.Lock()
+	defer inMemoryTopics.mu.Unlock()
+
+	t, ok := inMemoryTopics.topics[name]
+	if !ok {
+		t = new(inMemoryTopic)
+		inMemoryTopics.topics[name] = t
+	}
+	return t, nil
+}
+
+func newInMemoryConsumerEventClient(conf InMemoryConfig) (cloudevents.Client, error) {
+	t, err := getInMemoryTopic(conf.Topic)
+	if err != nil {
+		return nil, err
+	}
+	if conf.BufferSize <= 0 {
+		conf.BufferSize = 1024
+	}
+
+	r := &inMemoryReceiver{topic: t, ch: make(chan cloudevents.Event, conf.BufferSize)}
+	r.sub = t.subscribe(r.ch)
+
+	c, err := cloudevents.NewClient(r, orderedReceive...)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func newInMemoryProducerEventClient(conf InMemoryConfig) (cloudevents.Client, error) {
+	t, err := getInMemoryTopic(conf.Topic)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := cloudevents.NewClient(&inMemorySender{topic: t})
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+type inMemorySender struct {
+	topic *inMemoryTopic
+}
+
+func (s *inMemorySender) Send(ctx context.Context, in binding.Message, transformers ...binding.Transformer) (err error) {
+	defer func() {
+		if err2 := in.Finish(err); err2 != nil && err == nil {
+			err = err2
+		}
+	}()
+
+	e, err := binding.ToEvent(ctx, in, transformers...)
+	if err != nil {
+		return err
+	}
+
+	s.topic.mu.RLock()
+	subs := append([]*inMemorySub(nil), s.topic.subs...)
+	s.topic.mu.RUnlock()
+
+	for _, sub := range subs {
+		select {
+		case sub.ch <- e.Clone():
+		case <-sub.gone:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+type inMemoryReceiver struct {
+	topic *inMemoryTopic
+	ch    chan cloudevents.Event
+	mu    sync.Mutex
+	sub   *inMemorySub
+}
+
+// OpenInbound runs for as long as the receiver and unsubscribes once it
+// returns, so producers never wait on a consumer nobody reads. Starting the
+// receiver again subscribes anew.
+func (r *inMemoryReceiver) OpenInbound(ctx context.Context) error {
+	r.mu.Lock()
+	if r.sub == nil {
+		r.sub = r.topic.subscribe(r.ch)
+	}
+	r.mu.Unlock()
+
+	<-ctx.Done()
+
+	r.mu.Lock()
+	r.topic.unsubscribe(r.sub)
+	r.sub = nil
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *inMemoryReceiver) Receive(ctx context.Context) (binding.Message, error) {
+	select {
+	case <-ctx.Done():
+		return nil, io.EOF
+	case e := <-r.ch:
+		return (*binding.EventMessage)(&e), nil
+	}
+}
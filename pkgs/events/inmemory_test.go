@@ -0,0 +1,55 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/atgane/opentd/pkgs/events"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryUnsubscribe(t *testing.T) {
+	conf := events.EventConfig{
+		EventType:      events.INMEMORY,
+		InMemoryConfig: events.InMemoryConfig{Topic: "unsubscribe", BufferSize: 1},
+	}
+
+	producer, err := events.NewProducerEvent(conf)
+	require.NoError(t, err)
+	consumer, err := events.NewConsumerEvent(conf)
+	require.NoError(t, err)
+
+	send := func(id string) {
+		t.Helper()
+
+		e := cloudevents.NewEvent()
+		e.SetID(id)
+		e.SetType(events.BuyType)
+		e.SetSource(events.FrontendSource)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		require.False(t, cloudevents.IsUndelivered(producer.Send(ctx, e)))
+	}
+
+	received := make(chan string)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		_ = consumer.StartReceiver(ctx, func(e cloudevents.Event) {
+			received <- e.ID()
+		})
+	}()
+
+	send("e1")
+	require.Equal(t, "e1", <-received)
+
+	// once the receiver is gone producers no longer wait on its buffer
+	cancel()
+	<-stopped
+	for _, id := range []string{"e2", "e3", "e4"} {
+		send(id)
+	}
+}
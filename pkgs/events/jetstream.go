@@ -0,0 +1,181 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	cenats "github.com/cloudevents/sdk-go/protocol/nats/v2"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	"github.com/nats-io/nats.go"
+)
+
+type JetStreamConfig struct {
+	NATSServer  string
+	Stream      string
+	Subject     string
+	Durable     string
+	AckWait     time.Duration
+	MaxDeliver  int
+	NATSOptions []nats.Option
+}
+
+func newJetStreamConsumerEventClient(conf JetStreamConfig) (cloudevents.Client, error) {
+	if conf.Durable == "" {
+		return nil, fmt.Errorf("jetstream durable name is required")
+	}
+
+	js, err := connectJetStream(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	// a single unacked message at a time keeps redeliveries in publish order
+	opts := []nats.SubOpt{
+		nats.BindStream(conf.Stream),
+		nats.ManualAck(),
+		nats.AckExplicit(),
+		nats.DeliverAll(),
+		nats.MaxAckPending(1),
+	}
+	if conf.AckWait > 0 {
+		opts = append(opts, nats.AckWait(conf.AckWait))
+	}
+	if conf.MaxDeliver > 0 {
+		opts = append(opts, nats.MaxDeliver(conf.MaxDeliver))
+	}
+
+	sub, err := js.PullSubscribe(conf.Subject, conf.Durable, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := cloudevents.NewClient(&jetStreamReceiver{sub: sub}, orderedReceive...)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func newJetStreamProducerEventClient(conf JetStreamConfig) (cloudevents.Client, error) {
+	js, err := connectJetStream(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := cloudevents.NewClient(&jetStreamSender{js: js, subject: conf.Subject})
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// connectJetStream creates the stream on first use so producers and consumers
+// can start in any order.
+func connectJetStream(conf JetStreamConfig) (nats.JetStreamContext, error) {
+	if conf.Stream == "" || conf.Subject == "" {
+		return nil, fmt.Errorf("jetstream stream and subject are required")
+	}
+
+	conn, err := nats.Connect(conf.NATSServer, conf.NATSOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	_, err = js.StreamInfo(conf.Stream)
+	if errors.Is(err, nats.ErrStreamNotFound) {
+		_, err = js.AddStream(&nats.StreamConfig{
+			Name:     conf.Stream,
+			Subjects: []string{conf.Subject},
+			Storage:  nats.FileStorage,
+		})
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return js, nil
+}
+
+// jetStreamSender publishes structured events and only returns once the
+// stream has acknowledged them. The event id doubles as the JetStream message
+// id so a retried publish is deduplicated by the server.
+type jetStreamSender struct {
+	js      nats.JetStreamContext
+	subject string
+}
+
+func (s *jetStreamSender) Send(ctx context.Context, in binding.Message, transformers ...binding.Transformer) (err error) {
+	defer func() {
+		if err2 := in.Finish(err); err2 != nil && err == nil {
+			err = err2
+		}
+	}()
+
+	e, err := binding.ToEvent(ctx, in, transformers...)
+	if err != nil {
+		return err
+	}
+
+	writer := new(bytes.Buffer)
+	if err = cenats.WriteMsg(ctx, (*binding.EventMessage)(e), writer); err != nil {
+		return err
+	}
+
+	_, err = s.js.Publish(s.subject, writer.Bytes(), nats.MsgId(e.ID()), nats.Context(ctx))
+	return err
+}
+
+const fetchTimeout = 5 * time.Second
+
+type jetStreamReceiver struct {
+	sub *nats.Subscription
+}
+
+func (r *jetStreamReceiver) Receive(ctx context.Context) (binding.Message, error) {
+	for {
+		fetchCtx, cancel := context.WithTimeout(ctx, fetchTimeout)
+		msgs, err := r.sub.Fetch(1, nats.Context(fetchCtx))
+		cancel()
+
+		switch {
+		case ctx.Err() != nil:
+			return nil, io.EOF
+		case errors.Is(err, context.DeadlineExceeded), errors.Is(err, nats.ErrTimeout):
+			continue
+		case err != nil:
+			return nil, err
+		case len(msgs) == 0:
+			continue
+		}
+
+		return &jetStreamMessage{Message: cenats.NewMessage(msgs[0])}, nil
+	}
+}
+
+// jetStreamMessage acks once the receiver has applied the event and naks
+// otherwise so the server redelivers it.
+type jetStreamMessage struct {
+	*cenats.Message
+}
+
+func (m *jetStreamMessage) Finish(err error) error {
+	if protocol.IsACK(err) {
+		return m.Msg.AckSync()
+	}
+	return m.Msg.Nak()
+}
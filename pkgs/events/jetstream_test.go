@@ -0,0 +1,116 @@
+package events_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/atgane/opentd/pkgs/events"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/stretchr/testify/require"
+)
+
+func runJetStream(t *testing.T) string {
+	t.Helper()
+
+	s, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+	})
+	require.NoError(t, err)
+	go s.Start()
+	t.Cleanup(s.Shutdown)
+	require.True(t, s.ReadyForConnections(5*time.Second))
+	return s.ClientURL()
+}
+
+func receiveIDs(t *testing.T, received <-chan string, n int) []string {
+	t.Helper()
+
+	var ids []string
+	for len(ids) < n {
+		select {
+		case id := <-received:
+			ids = append(ids, id)
+		case <-time.After(10 * time.Second):
+			require.FailNow(t, "events were not received", "%v", ids)
+		}
+	}
+	return ids
+}
+
+func TestJetStream(t *testing.T) {
+	conf := events.EventConfig{
+		EventType: events.JETSTREAM,
+		JetStreamConfig: events.JetStreamConfig{
+			NATSServer: runJetStream(t),
+			Stream:     "orders",
+			Subject:    "orders.events",
+			Durable:    "dealer",
+			AckWait:    time.Second,
+		},
+	}
+
+	// the consumer is created first, publishing before any producer exists
+	// must not be required
+	consumer, err := events.NewConsumerEvent(conf)
+	require.NoError(t, err)
+	producer, err := events.NewProducerEvent(conf)
+	require.NoError(t, err)
+
+	send := func(id string) {
+		e := cloudevents.NewEvent()
+		e.SetID(id)
+		e.SetType(events.BuyType)
+		e.SetSource(events.FrontendSource)
+		require.NoError(t, e.SetData(cloudevents.ApplicationJSON, map[string]string{"id": id}))
+		require.False(t, cloudevents.IsUndelivered(producer.Send(context.Background(), e)))
+	}
+	for idx := 0; idx < 3; idx++ {
+		send(fmt.Sprintf("e%d", idx))
+	}
+	// a retried publish of the same event is dropped by the server
+	send("e1")
+
+	// e1 is rejected once and redelivered before e2
+	received := make(chan string, 8)
+	rejected := false
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		_ = consumer.StartReceiver(ctx, func(ctx context.Context, e cloudevents.Event) error {
+			if e.ID() == "e1" && !rejected {
+				rejected = true
+				return fmt.Errorf("not yet")
+			}
+			received <- e.ID()
+			return nil
+		})
+	}()
+	require.Equal(t, []string{"e0", "e1", "e2"}, receiveIDs(t, received, 3))
+	cancel()
+	<-stopped
+
+	// a new consumer on the same durable resumes after the acked events
+	send("e3")
+	consumer, err = events.NewConsumerEvent(conf)
+	require.NoError(t, err)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go consumer.StartReceiver(ctx, func(ctx context.Context, e cloudevents.Event) error {
+		received <- e.ID()
+		return nil
+	})
+	require.Equal(t, []string{"e3"}, receiveIDs(t, received, 1))
+
+	select {
+	case id := <-received:
+		require.FailNow(t, "unexpected redelivery", id)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
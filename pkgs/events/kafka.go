@@ -0,0 +1,214 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Shopify/sarama"
+	cekafka "github.com/cloudevents/sdk-go/protocol/kafka_sarama/v2"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	"github.com/cloudevents/sdk-go/v2/types"
+)
+
+type KafkaConfig struct {
+	Brokers       []string
+	Topic         string
+	ConsumerGroup string
+	// PartitionKey names the event extension used as the kafka message key.
+	// It defaults to PartitionKeyExtension, which the frontend fills with the
+	// order target so every order of a target lands on the same partition.
+	PartitionKey string
+	SaramaConfig *sarama.Config
+}
+
+func newKafkaConsumerEventClient(conf KafkaConfig) (cloudevents.Client, error) {
+	if conf.ConsumerGroup == "" {
+		return nil, fmt.Errorf("kafka consumer group is required")
+	}
+
+	client, err := sarama.NewClient(conf.Brokers, kafkaSaramaConfig(conf))
+	if err != nil {
+		return nil, err
+	}
+
+	r := &kafkaReceiver{
+		client:   client,
+		group:    conf.ConsumerGroup,
+		topic:    conf.Topic,
+		incoming: make(chan binding.Message),
+	}
+	c, err := cloudevents.NewClient(r, orderedReceive...)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func newKafkaProducerEventClient(conf KafkaConfig) (cloudevents.Client, error) {
+	s, err := cekafka.NewSender(conf.Brokers, kafkaSaramaConfig(conf), conf.Topic)
+	if err != nil {
+		return nil, err
+	}
+
+	key := conf.PartitionKey
+	if key == "" {
+		key = PartitionKeyExtension
+	}
+
+	c, err := cloudevents.NewClient(&kafkaSender{Sender: s, partitionKey: key})
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func kafkaSaramaConfig(conf KafkaConfig) *sarama.Config {
+	if conf.SaramaConfig != nil {
+		return conf.SaramaConfig
+	}
+
+	sc := sarama.NewConfig()
+	sc.Producer.RequiredAcks = sarama.WaitForAll
+	sc.Producer.Return.Successes = true
+	sc.Producer.Partitioner = sarama.NewHashPartitioner
+	sc.Consumer.Offsets.Initial = sarama.OffsetOldest
+	return sc
+}
+
+// kafkaSender keys every message with the configured partition key extension.
+type kafkaSender struct {
+	*cekafka.Sender
+	partitionKey string
+}
+
+func (s *kafkaSender) Send(ctx context.Context, in binding.Message, transformers ...binding.Transformer) error {
+	e, err := binding.ToEvent(ctx, in, transformers...)
+	if err != nil {
+		_ = in.Finish(err)
+		return err
+	}
+
+	if v, ok := e.Extensions()[s.partitionKey]; ok {
+		key, err := types.ToString(v)
+		if err != nil {
+			_ = in.Finish(err)
+			return err
+		}
+		ctx = cekafka.WithMessageKey(ctx, sarama.StringEncoder(key))
+	}
+
+	return s.Sender.Send(cekafka.WithSkipKeyMapping(ctx), in)
+}
+
+const (
+	kafkaRetryMin = 100 * time.Millisecond
+	kafkaRetryMax = 5 * time.Second
+)
+
+// kafkaReceiver hands the messages of every claimed partition over one at a
+// time. Kafka only keeps a committed offset per partition, so a nacked message
+// is handed over again until it is acked and no later offset is marked before.
+type kafkaReceiver struct {
+	client   sarama.Client
+	group    string
+	topic    string
+	incoming chan binding.Message
+}
+
+func (r *kafkaReceiver) OpenInbound(ctx context.Context) error {
+	cg, err := sarama.NewConsumerGroupFromClient(r.group, r.client)
+	if err != nil {
+		return err
+	}
+	defer cg.Close()
+
+	for ctx.Err() == nil {
+		err := cg.Consume(ctx, []string{r.topic}, r)
+		if errors.Is(err, sarama.ErrClosedClient) || errors.Is(err, sarama.ErrClosedConsumerGroup) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *kafkaReceiver) Receive(ctx context.Context) (binding.Message, error) {
+	select {
+	case <-ctx.Done():
+		return nil, io.EOF
+	case m := <-r.incoming:
+		return m, nil
+	}
+}
+
+func (r *kafkaReceiver) Close(ctx context.Context) error {
+	return r.client.Close()
+}
+
+func (r *kafkaReceiver) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (r *kafkaReceiver) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+func (r *kafkaReceiver) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for {
+		select {
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			if !r.deliver(session, msg) {
+				return nil
+			}
+		case <-session.Context().Done():
+			return nil
+		}
+	}
+}
+
+// deliver hands msg over until it is acked and marks it. It gives up without
+// marking when the session ends, the next owner of the partition consumes the
+// message again.
+func (r *kafkaReceiver) deliver(session sarama.ConsumerGroupSession, msg *sarama.ConsumerMessage) bool {
+	done := session.Context().Done()
+	for backoff := kafkaRetryMin; ; backoff = min(2*backoff, kafkaRetryMax) {
+		result := make(chan error, 1)
+		m := binding.WithFinish(cekafka.NewMessageFromConsumerMessage(msg), func(err error) {
+			result <- err
+		})
+
+		select {
+		case r.incoming <- m:
+		case <-done:
+			return false
+		}
+
+		select {
+		case err := <-result:
+			if protocol.IsACK(err) {
+				session.MarkMessage(msg, "")
+				return true
+			}
+		case <-done:
+			return false
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-done:
+			return false
+		}
+	}
+}
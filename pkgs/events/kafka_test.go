@@ -0,0 +1,164 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	cekafka "github.com/cloudevents/sdk-go/protocol/kafka_sarama/v2"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/stretchr/testify/require"
+)
+
+func newKafkaTestEvent(t *testing.T, id, target string) cloudevents.Event {
+	t.Helper()
+
+	e := cloudevents.NewEvent()
+	e.SetID(id)
+	e.SetType(BuyType)
+	e.SetSource(FrontendSource)
+	SetPartitionKey(&e, target)
+	require.NoError(t, e.SetData(cloudevents.ApplicationJSON, map[string]string{"target": target}))
+	return e
+}
+
+func TestKafkaProducerAgainstSingleBroker(t *testing.T) {
+	topic := "orders"
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader(topic, 0, broker.BrokerID()).
+			SetLeader(topic, 1, broker.BrokerID()),
+		"ProduceRequest": sarama.NewMockProduceResponse(t).SetVersion(3),
+	})
+
+	c, err := NewProducerEvent(EventConfig{
+		EventType: KAFKA,
+		KafkaConfig: KafkaConfig{
+			Brokers: []string{broker.Addr()},
+			Topic:   topic,
+		},
+	})
+	require.NoError(t, err)
+
+	result := c.Send(context.Background(), newKafkaTestEvent(t, "e1", "target-a"))
+	require.False(t, cloudevents.IsUndelivered(result), "%v", result)
+
+	var produced int
+	for _, rr := range broker.History() {
+		if _, ok := rr.Request.(*sarama.ProduceRequest); ok {
+			produced++
+		}
+	}
+	require.Equal(t, 1, produced)
+}
+
+func TestKafkaSenderKeysByTarget(t *testing.T) {
+	topic := "orders"
+	targets := []string{"target-a", "target-b", "target-a"}
+
+	producer := mocks.NewSyncProducer(t, nil)
+	for _, target := range targets {
+		target := target
+		producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+			key, err := msg.Key.Encode()
+			require.NoError(t, err)
+			require.Equal(t, target, string(key))
+			require.Equal(t, topic, msg.Topic)
+			return nil
+		})
+	}
+
+	s, err := cekafka.NewSenderFromSyncProducer(topic, producer)
+	require.NoError(t, err)
+	c, err := cloudevents.NewClient(&kafkaSender{Sender: s, partitionKey: PartitionKeyExtension})
+	require.NoError(t, err)
+
+	for idx, target := range targets {
+		e := newKafkaTestEvent(t, string(rune('a'+idx)), target)
+		result := c.Send(context.Background(), e)
+		require.False(t, cloudevents.IsUndelivered(result), "%v", result)
+	}
+	require.NoError(t, producer.Close())
+}
+
+type kafkaTestSession struct {
+	sarama.ConsumerGroupSession
+	ctx    context.Context
+	marked chan int64
+}
+
+func (s *kafkaTestSession) Context() context.Context {
+	return s.ctx
+}
+
+func (s *kafkaTestSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	s.marked <- msg.Offset
+}
+
+type kafkaTestClaim struct {
+	sarama.ConsumerGroupClaim
+	messages chan *sarama.ConsumerMessage
+}
+
+func (c *kafkaTestClaim) Messages() <-chan *sarama.ConsumerMessage {
+	return c.messages
+}
+
+func TestKafkaReceiverRetriesNackedMessage(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	claim := &kafkaTestClaim{messages: make(chan *sarama.ConsumerMessage, 3)}
+	for idx := 0; idx < 3; idx++ {
+		e := newKafkaTestEvent(t, fmt.Sprintf("e%d", idx), "target-a")
+		pm := new(sarama.ProducerMessage)
+		require.NoError(t, cekafka.WriteProducerMessage(ctx, (*binding.EventMessage)(&e), pm))
+
+		value, err := pm.Value.Encode()
+		require.NoError(t, err)
+		msg := &sarama.ConsumerMessage{Offset: int64(idx), Value: value}
+		for idx := range pm.Headers {
+			msg.Headers = append(msg.Headers, &pm.Headers[idx])
+		}
+		claim.messages <- msg
+	}
+
+	r := &kafkaReceiver{incoming: make(chan binding.Message)}
+	session := &kafkaTestSession{ctx: ctx, marked: make(chan int64, 3)}
+	go r.ConsumeClaim(session, claim)
+
+	// e1 is nacked twice and handed over again before e2, and its offset is
+	// not marked until it is acked
+	var ids []string
+	nacks := 0
+	for len(ids) < 5 {
+		m, err := r.Receive(ctx)
+		require.NoError(t, err)
+		e, err := binding.ToEvent(ctx, m)
+		require.NoError(t, err)
+		ids = append(ids, e.ID())
+
+		if e.ID() == "e1" && nacks < 2 {
+			nacks++
+			require.NoError(t, m.Finish(fmt.Errorf("not yet")))
+			require.Empty(t, session.marked)
+			continue
+		}
+		require.NoError(t, m.Finish(nil))
+		select {
+		case offset := <-session.marked:
+			require.Equal(t, fmt.Sprintf("e%d", offset), e.ID())
+		case <-time.After(time.Second):
+			require.FailNow(t, "offset was not marked", e.ID())
+		}
+	}
+	require.Equal(t, []string{"e0", "e1", "e1", "e1", "e2"}, ids)
+}
@@ -18,7 +18,7 @@ func newNATSConsumerEventClient(conf NATSConfig) (cloudevents.Client, error) {
 		return nil, err
 	}
 
-	c, err := cloudevents.NewClient(p)
+	c, err := cloudevents.NewClient(p, orderedReceive...)
 	if err != nil {
 		return nil, err
 	}
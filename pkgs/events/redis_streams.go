@@ -0,0 +1,285 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/cloudevents/sdk-go/v2/binding/format"
+	"github.com/cloudevents/sdk-go/v2/protocol"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisStreamsEventField = "event"
+	redisStreamsRetryMin   = 100 * time.Millisecond
+	redisStreamsRetryMax   = 5 * time.Second
+)
+
+type RedisStreamsConfig struct {
+	RedisOptions redis.Options
+	Stream       string
+	Group        string
+	Consumer     string
+	// MaxLen approximately caps the stream length on every XADD, 0 keeps all entries.
+	MaxLen int64
+	// ClaimMinIdle is how long an entry must stay unacked in another consumer
+	// before it is reclaimed with XAUTOCLAIM.
+	ClaimMinIdle time.Duration
+	BlockTimeout time.Duration
+}
+
+func newRedisStreamsConsumerEventClient(conf RedisStreamsConfig) (cloudevents.Client, error) {
+	if conf.Stream == "" || conf.Group == "" || conf.Consumer == "" {
+		return nil, fmt.Errorf("redis streams stream, group and consumer are required")
+	}
+	if conf.ClaimMinIdle <= 0 {
+		conf.ClaimMinIdle = 30 * time.Second
+	}
+	if conf.BlockTimeout <= 0 {
+		conf.BlockTimeout = 5 * time.Second
+	}
+
+	ctx := context.Background()
+	redisClient := redis.NewClient(&conf.RedisOptions)
+	err := redisClient.XGroupCreateMkStream(ctx, conf.Stream, conf.Group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		redisClient.Close()
+		return nil, err
+	}
+
+	r := &redisStreamsReceiver{redisClient: redisClient, conf: conf, cursor: "0"}
+	c, err := cloudevents.NewClient(r, orderedReceive...)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func newRedisStreamsProducerEventClient(conf RedisStreamsConfig) (cloudevents.Client, error) {
+	if conf.Stream == "" {
+		return nil, fmt.Errorf("redis streams stream is required")
+	}
+
+	redisClient := redis.NewClient(&conf.RedisOptions)
+	if err := redisClient.Ping(context.Background()).Err(); err != nil {
+		redisClient.Close()
+		return nil, err
+	}
+
+	c, err := cloudevents.NewClient(&redisStreamsSender{redisClient: redisClient, conf: conf})
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+type redisStreamsSender struct {
+	redisClient *redis.Client
+	conf        RedisStreamsConfig
+}
+
+func (s *redisStreamsSender) Send(ctx context.Context, in binding.Message, transformers ...binding.Transformer) (err error) {
+	defer func() {
+		if err2 := in.Finish(err); err2 != nil && err == nil {
+			err = err2
+		}
+	}()
+
+	e, err := binding.ToEvent(ctx, in, transformers...)
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	args := &redis.XAddArgs{
+		Stream: s.conf.Stream,
+		Values: []interface{}{redisStreamsEventField, b},
+	}
+	if s.conf.MaxLen > 0 {
+		args.MaxLen = s.conf.MaxLen
+		args.Approx = true
+	}
+	return s.redisClient.XAdd(ctx, args).Err()
+}
+
+// redisStreamsReceiver first drains this consumer's own pending entries, which
+// are left over from a previous run, then takes over entries other consumers
+// left unacked for ClaimMinIdle, and only then reads new entries. The claim
+// pass is repeated every ClaimMinIdle while reading. A nacked entry goes back
+// to the head of the queue and is handed over again after a backoff, so no
+// later entry overtakes it.
+type redisStreamsReceiver struct {
+	redisClient *redis.Client
+	conf        RedisStreamsConfig
+	// cursor is "0" while replaying own pending entries, then ">".
+	cursor    string
+	claimNext string
+	lastClaim time.Time
+	queue     []redis.XMessage
+	backoff   time.Duration
+}
+
+func (r *redisStreamsReceiver) Receive(ctx context.Context) (binding.Message, error) {
+	if r.backoff > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, io.EOF
+		case <-time.After(r.backoff):
+		}
+	}
+
+	for len(r.queue) == 0 {
+		if ctx.Err() != nil {
+			return nil, io.EOF
+		}
+		if err := r.fill(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil, io.EOF
+			}
+			return nil, err
+		}
+	}
+
+	msg := r.queue[0]
+	r.queue = r.queue[1:]
+
+	data, ok := msg.Values[redisStreamsEventField].(string)
+	if !ok {
+		// an entry we cannot decode would otherwise be redelivered forever
+		_ = r.redisClient.XAck(ctx, r.conf.Stream, r.conf.Group, msg.ID).Err()
+		return nil, fmt.Errorf("redis stream entry %s has no %s field", msg.ID, redisStreamsEventField)
+	}
+
+	return &redisStreamsMessage{receiver: r, msg: msg, data: []byte(data)}, nil
+}
+
+func (r *redisStreamsReceiver) retry(msg redis.XMessage) {
+	r.queue = append([]redis.XMessage{msg}, r.queue...)
+	r.backoff = min(max(2*r.backoff, redisStreamsRetryMin), redisStreamsRetryMax)
+}
+
+func (r *redisStreamsReceiver) fill(ctx context.Context) error {
+	if r.cursor == "0" {
+		msgs, err := r.read(ctx, "0", -1)
+		if err != nil {
+			return err
+		}
+		if len(msgs) == 0 {
+			r.cursor = ">"
+			return r.claim(ctx)
+		}
+		r.queue = msgs
+		return nil
+	}
+
+	if time.Since(r.lastClaim) >= r.conf.ClaimMinIdle {
+		if err := r.claim(ctx); err != nil {
+			return err
+		}
+		if len(r.queue) > 0 {
+			return nil
+		}
+	}
+
+	msgs, err := r.read(ctx, ">", r.conf.BlockTimeout)
+	if err != nil {
+		return err
+	}
+	r.queue = msgs
+	return nil
+}
+
+func (r *redisStreamsReceiver) read(ctx context.Context, id string, block time.Duration) ([]redis.XMessage, error) {
+	streams, err := r.redisClient.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    r.conf.Group,
+		Consumer: r.conf.Consumer,
+		Streams:  []string{r.conf.Stream, id},
+		Count:    100,
+		Block:    block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var msgs []redis.XMessage
+	for _, s := range streams {
+		msgs = append(msgs, s.Messages...)
+	}
+	return msgs, nil
+}
+
+// claim runs one XAUTOCLAIM page and remembers where the next page starts.
+func (r *redisStreamsReceiver) claim(ctx context.Context) error {
+	start := r.claimNext
+	if start == "" {
+		start = "0-0"
+	}
+
+	msgs, next, err := r.redisClient.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   r.conf.Stream,
+		Group:    r.conf.Group,
+		Consumer: r.conf.Consumer,
+		MinIdle:  r.conf.ClaimMinIdle,
+		Start:    start,
+		Count:    100,
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	if next == "0-0" {
+		r.claimNext = ""
+		r.lastClaim = time.Now()
+	} else {
+		r.claimNext = next
+	}
+	r.queue = append(r.queue, msgs...)
+	return nil
+}
+
+// redisStreamsMessage is acked with XACK only when the receiver accepted the
+// event; otherwise it stays pending and is handed over again.
+type redisStreamsMessage struct {
+	receiver *redisStreamsReceiver
+	msg      redis.XMessage
+	data     []byte
+}
+
+func (m *redisStreamsMessage) ReadEncoding() binding.Encoding {
+	return binding.EncodingStructured
+}
+
+func (m *redisStreamsMessage) ReadStructured(ctx context.Context, encoder binding.StructuredWriter) error {
+	return encoder.SetStructuredEvent(ctx, format.JSON, bytes.NewReader(m.data))
+}
+
+func (m *redisStreamsMessage) ReadBinary(ctx context.Context, encoder binding.BinaryWriter) error {
+	return binding.ErrNotBinary
+}
+
+func (m *redisStreamsMessage) Finish(err error) error {
+	r := m.receiver
+	if !protocol.IsACK(err) {
+		r.retry(m.msg)
+		return nil
+	}
+
+	r.backoff = 0
+	return r.redisClient.XAck(context.Background(), r.conf.Stream, r.conf.Group, m.msg.ID).Err()
+}
@@ -0,0 +1,74 @@
+package events_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/atgane/opentd/pkgs/events"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisStreams(t *testing.T) {
+	mr := miniredis.RunT(t)
+	conf := events.EventConfig{
+		EventType: events.REDIS_STREAMS,
+		RedisStreamsConfig: events.RedisStreamsConfig{
+			RedisOptions: redis.Options{Addr: mr.Addr()},
+			Stream:       "orders",
+			Group:        "dealer",
+			Consumer:     "dealer-0",
+			ClaimMinIdle: time.Millisecond,
+			BlockTimeout: 10 * time.Millisecond,
+		},
+	}
+
+	producer, err := events.NewProducerEvent(conf)
+	require.NoError(t, err)
+	consumer, err := events.NewConsumerEvent(conf)
+	require.NoError(t, err)
+
+	for idx := 0; idx < 3; idx++ {
+		e := cloudevents.NewEvent()
+		e.SetID(fmt.Sprintf("e%d", idx))
+		e.SetType(events.BuyType)
+		e.SetSource(events.FrontendSource)
+		require.NoError(t, e.SetData(cloudevents.ApplicationJSON, map[string]int{"idx": idx}))
+		require.False(t, cloudevents.IsUndelivered(producer.Send(context.Background(), e)))
+	}
+
+	// e1 is rejected once and handed over again before e2
+	received := make(chan string, 8)
+	rejected := false
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go consumer.StartReceiver(ctx, func(ctx context.Context, e cloudevents.Event) error {
+		if e.ID() == "e1" && !rejected {
+			rejected = true
+			return fmt.Errorf("not yet")
+		}
+		received <- e.ID()
+		return nil
+	})
+
+	var ids []string
+	for len(ids) < 3 {
+		select {
+		case id := <-received:
+			ids = append(ids, id)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "events were not received", "%v", ids)
+		}
+	}
+	require.Equal(t, []string{"e0", "e1", "e2"}, ids)
+
+	require.Eventually(t, func() bool {
+		pending, err := redis.NewClient(&conf.RedisStreamsConfig.RedisOptions).
+			XPending(context.Background(), "orders", "dealer").Result()
+		return err == nil && pending.Count == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -1,14 +1,70 @@
 package events
 
+import (
+	"strconv"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/types"
+)
+
 const (
 	FrontendSource = "opentd/frontend"
+	DealerSource   = "opentd/dealer"
 )
 
 const (
-	BuyType        = "com.atgane.opentd.Buy.Order"
-	SellType       = "com.atgane.opentd.Sell.Order"
-	CancelType     = "com.atgane.opentd.Cancel.Order"
-	UpdateBuyType  = "com.atgane.opentd.UpdateBuy.Order"
-	UpdateSellType = "com.atgane.opentd.UpdateSell.Order"
-	DealType       = "com.atgane.opentd.Stream.Deal"
+	BuyType         = "com.atgane.opentd.Buy.Order"
+	SellType        = "com.atgane.opentd.Sell.Order"
+	CancelType      = "com.atgane.opentd.Cancel.Order"
+	UpdateBuyType   = "com.atgane.opentd.UpdateBuy.Order"
+	UpdateSellType  = "com.atgane.opentd.UpdateSell.Order"
+	ExpireType      = "com.atgane.opentd.Expire.Order"
+	DealType        = "com.atgane.opentd.Stream.Deal"
+	SelfTradeType   = "com.atgane.opentd.Stream.SelfTrade"
+	OrderUpdateType = "com.atgane.opentd.OrderUpdate"
+	L3Type          = "com.atgane.opentd.Stream.L3"
 )
+
+// PartitionKeyExtension is the cloudevents partitioning extension. Order
+// events carry their target in it so brokers that partition, like kafka, keep
+// per-target ordering.
+const PartitionKeyExtension = "partitionkey"
+
+func SetPartitionKey(e *cloudevents.Event, key string) {
+	if key != "" {
+		e.SetExtension(PartitionKeyExtension, key)
+	}
+}
+
+// SequenceExtension carries the journal sequence the dealer assigned to an
+// order event.
+const SequenceExtension = "dealerseq"
+
+func IsOrderType(t string) bool {
+	switch t {
+	case BuyType, SellType, CancelType, UpdateBuyType, UpdateSellType, ExpireType:
+		return true
+	}
+	return false
+}
+
+func SetSequence(e *cloudevents.Event, seq uint64) {
+	e.SetExtension(SequenceExtension, strconv.FormatUint(seq, 10))
+}
+
+func Sequence(e cloudevents.Event) (uint64, bool) {
+	v, ok := e.Extensions()[SequenceExtension]
+	if !ok {
+		return 0, false
+	}
+
+	s, err := types.ToString(v)
+	if err != nil {
+		return 0, false
+	}
+	seq, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return seq, true
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/atgane/opentd/apis"
 	"github.com/atgane/opentd/pkgs/events"
+	"github.com/atgane/opentd/pkgs/orders"
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
@@ -18,8 +20,20 @@ import (
 )
 
 type FrontConfig struct {
-	GRPCPort         int
-	EventConfig      events.EventConfig
+	GRPCPort    int
+	EventConfig events.EventConfig
+	// OrderUpdateConfig consumes the order updates the dealer publishes. Every
+	// frontend needs all of them, so it must not be a shared queue.
+	OrderUpdateConfig events.EventConfig
+	// UpdateBufferSize is how many order updates a StreamOrderUpdates client
+	// can lag behind before it is disconnected.
+	UpdateBufferSize int
+	// DataContentType is the order event data encoding, json by default or
+	// events.ApplicationProtobuf.
+	DataContentType  string
+	OrderStoreConfig orders.OrderStoreConfig
+	RiskConfig       RiskConfig
+	RateLimitConfig  RateLimitConfig
 	RedisConfig      redis.Options
 	LogLevel         string
 	LockExpireSecond time.Duration
@@ -27,15 +41,29 @@ type FrontConfig struct {
 
 type Frontend struct {
 	producerClient   cloudevents.Client
+	updateClient     cloudevents.Client
 	redisClient      *redis.Client
+	orders           *orders.Store
+	updates          *updateHub
+	risk             *riskChecker
 	port             int
 	lockExpireSecond time.Duration
+	dataContentType  string
 	gs               *grpc.Server
+	done             chan struct{}
+	stopOnce         sync.Once
 
 	apis.UnimplementedFrontendServer
 }
 
 func NewFrontend(conf FrontConfig) (*Frontend, error) {
+	if err := conf.RateLimitConfig.validate(); err != nil {
+		return nil, err
+	}
+	if err := events.CheckContentType(conf.DataContentType); err != nil {
+		return nil, err
+	}
+
 	ctx := context.Background()
 
 	producerClient, err := events.NewProducerEvent(conf.EventConfig)
@@ -44,19 +72,40 @@ func NewFrontend(conf FrontConfig) (*Frontend, error) {
 	}
 	log.Debug().Msg("event consumer client initializing success")
 
+	updateClient, err := events.NewConsumerEvent(conf.OrderUpdateConfig)
+	if err != nil {
+		return nil, err
+	}
+
 	redisClient := redis.NewClient(&conf.RedisConfig)
 	if err := redisClient.Ping(ctx).Err(); err != nil {
 		return nil, err
 	}
 
+	orderStore, err := orders.NewStore(conf.OrderStoreConfig, redisClient)
+	if err != nil {
+		return nil, err
+	}
+
+	limiter := newRateLimiter(conf.RateLimitConfig, redisClient)
 	// TODO: TLS certificate branch
-	gs := grpc.NewServer()
+	var opts []grpc.ServerOption
+	if limiter != nil {
+		opts = append(opts, grpc.UnaryInterceptor(limiter.unary), grpc.StreamInterceptor(limiter.stream))
+	}
+	gs := grpc.NewServer(opts...)
 	fs := new(Frontend)
 	fs.producerClient = producerClient
+	fs.updateClient = updateClient
 	fs.redisClient = redisClient
+	fs.orders = orderStore
+	fs.updates = newUpdateHub(conf.UpdateBufferSize)
+	fs.risk = newRiskChecker(conf.RiskConfig, redisClient)
 	fs.port = conf.GRPCPort
 	fs.lockExpireSecond = conf.LockExpireSecond
+	fs.dataContentType = conf.DataContentType
 	fs.gs = gs
+	fs.done = make(chan struct{})
 	apis.RegisterFrontendServer(gs, fs)
 	return fs, nil
 }
@@ -66,14 +115,51 @@ func (f *Frontend) Start() error {
 	if err != nil {
 		return err
 	}
+	return f.Serve(l)
+}
 
-	return f.gs.Serve(l)
+// Serve runs the frontend like Start on a listener of the caller.
+func (f *Frontend) Serve(l net.Listener) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errChan := make(chan error, 2)
+	go func() {
+		errChan <- f.gs.Serve(l)
+	}()
+	go func() {
+		for ctx.Err() == nil {
+			if err := f.updateClient.StartReceiver(ctx, f.receiveUpdate); err != nil {
+				errChan <- err
+				return
+			}
+		}
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-f.done:
+		return nil
+	}
+}
+
+// Stop ends the order update streams and stops the server.
+func (f *Frontend) Stop() {
+	f.stopOnce.Do(func() {
+		close(f.done)
+		f.gs.GracefulStop()
+	})
 }
 
 func (f *Frontend) Buy(ctx context.Context, req *apis.BuyRequest) (*apis.BuyResponse, error) {
 	// TODO: add otel tracing
 	log.Debug().Interface("req", req).Msg("buy order accepted")
 
+	if err := validateOrder(req); err != nil {
+		return nil, err
+	}
+
 	rid := uuid.New()
 
 	e := cloudevents.NewEvent()
@@ -81,7 +167,23 @@ func (f *Frontend) Buy(ctx context.Context, req *apis.BuyRequest) (*apis.BuyResp
 	e.SetType(events.BuyType)
 	e.SetTime(time.Now())
 	e.SetSource(events.FrontendSource)
-	_ = e.SetData(cloudevents.ApplicationJSON, req)
+	events.SetPartitionKey(&e, req.Target)
+	if err := events.SetData(&e, f.dataContentType, req); err != nil {
+		log.Error().
+			Err(err).
+			Str("user_id", req.UserId).
+			Str("target", req.Target).
+			Msg("failed to events.SetData()")
+		return nil, err
+	}
+
+	if err := f.hold(ctx, e, apis.Side_BUY, req); err != nil {
+		return nil, err
+	}
+	if err := f.savePending(ctx, e, apis.Side_BUY, req); err != nil {
+		f.release(ctx, e, req)
+		return nil, err
+	}
 
 	if result := f.producerClient.Send(ctx, e); cloudevents.IsUndelivered(result) {
 		err := fmt.Errorf("cloud event message send failed")
@@ -92,6 +194,8 @@ func (f *Frontend) Buy(ctx context.Context, req *apis.BuyRequest) (*apis.BuyResp
 			Int64("amount", req.Amount).
 			Int64("price", req.Price).
 			Msg("failed to f.producerClient.Send()")
+		f.deletePending(ctx, e)
+		f.release(ctx, e, req)
 		return nil, err
 	}
 
@@ -104,6 +208,10 @@ func (f *Frontend) Buy(ctx context.Context, req *apis.BuyRequest) (*apis.BuyResp
 func (f *Frontend) Sell(ctx context.Context, req *apis.SellRequest) (*apis.SellResponse, error) {
 	log.Debug().Interface("req", req).Msg("sell order accepted")
 
+	if err := validateOrder(req); err != nil {
+		return nil, err
+	}
+
 	rid := uuid.New()
 
 	e := cloudevents.NewEvent()
@@ -111,7 +219,23 @@ func (f *Frontend) Sell(ctx context.Context, req *apis.SellRequest) (*apis.SellR
 	e.SetType(events.SellType)
 	e.SetTime(time.Now())
 	e.SetSource(events.FrontendSource)
-	_ = e.SetData(cloudevents.ApplicationJSON, req)
+	events.SetPartitionKey(&e, req.Target)
+	if err := events.SetData(&e, f.dataContentType, req); err != nil {
+		log.Error().
+			Err(err).
+			Str("user_id", req.UserId).
+			Str("target", req.Target).
+			Msg("failed to events.SetData()")
+		return nil, err
+	}
+
+	if err := f.hold(ctx, e, apis.Side_SELL, req); err != nil {
+		return nil, err
+	}
+	if err := f.savePending(ctx, e, apis.Side_SELL, req); err != nil {
+		f.release(ctx, e, req)
+		return nil, err
+	}
 
 	if result := f.producerClient.Send(ctx, e); cloudevents.IsUndelivered(result) {
 		err := fmt.Errorf("cloud event message send failed")
@@ -122,6 +246,8 @@ func (f *Frontend) Sell(ctx context.Context, req *apis.SellRequest) (*apis.SellR
 			Int64("amount", req.Amount).
 			Int64("price", req.Price).
 			Msg("failed to f.producerClient.Send()")
+		f.deletePending(ctx, e)
+		f.release(ctx, e, req)
 		return nil, err
 	}
 
@@ -134,6 +260,12 @@ func (f *Frontend) Sell(ctx context.Context, req *apis.SellRequest) (*apis.SellR
 func (f *Frontend) Cancel(ctx context.Context, req *apis.CancelRequest) (*apis.CancelResponse, error) {
 	log.Debug().Interface("req", req).Msg("cancel order accepted")
 
+	target, err := f.orderTarget(ctx, req.UserId, req.RequestId, req.Target)
+	if err != nil {
+		return nil, err
+	}
+	req.Target = target
+
 	result := f.redisClient.SetNX(ctx, req.RequestId, 1, f.lockExpireSecond)
 	success, err := result.Result()
 	if err != nil {
@@ -161,7 +293,15 @@ func (f *Frontend) Cancel(ctx context.Context, req *apis.CancelRequest) (*apis.C
 	e.SetType(events.CancelType)
 	e.SetTime(time.Now())
 	e.SetSource(events.FrontendSource)
-	_ = e.SetData(cloudevents.ApplicationJSON, req)
+	events.SetPartitionKey(&e, req.Target)
+	if err := events.SetData(&e, f.dataContentType, req); err != nil {
+		log.Error().
+			Err(err).
+			Str("user_id", req.UserId).
+			Str("target", req.Target).
+			Msg("failed to events.SetData()")
+		return nil, err
+	}
 
 	if result := f.producerClient.Send(ctx, e); cloudevents.IsUndelivered(result) {
 		err := fmt.Errorf("cloud event message send failed")
@@ -182,6 +322,12 @@ func (f *Frontend) Cancel(ctx context.Context, req *apis.CancelRequest) (*apis.C
 func (f *Frontend) UpdateBuy(ctx context.Context, req *apis.UpdateRequest) (*apis.UpdateResponse, error) {
 	log.Debug().Interface("req", req).Msg("update buy order accepted")
 
+	target, err := f.orderTarget(ctx, req.UserId, req.RequestId, req.Target)
+	if err != nil {
+		return nil, err
+	}
+	req.Target = target
+
 	result := f.redisClient.SetNX(ctx, req.RequestId, 1, f.lockExpireSecond)
 	success, err := result.Result()
 	if err != nil {
@@ -208,6 +354,13 @@ func (f *Frontend) UpdateBuy(ctx context.Context, req *apis.UpdateRequest) (*api
 		return nil, status.Errorf(codes.AlreadyExists, msg)
 	}
 
+	// a refused amend must not keep the order locked
+	repriced, err := f.reprice(ctx, apis.Side_BUY, req)
+	if err != nil {
+		f.unlock(ctx, req.RequestId)
+		return nil, err
+	}
+
 	rid := uuid.New()
 
 	e := cloudevents.NewEvent()
@@ -215,7 +368,16 @@ func (f *Frontend) UpdateBuy(ctx context.Context, req *apis.UpdateRequest) (*api
 	e.SetType(events.UpdateBuyType)
 	e.SetTime(time.Now())
 	e.SetSource(events.FrontendSource)
-	_ = e.SetData(cloudevents.ApplicationJSON, req)
+	events.SetPartitionKey(&e, req.Target)
+	if err := events.SetData(&e, f.dataContentType, req); err != nil {
+		log.Error().
+			Err(err).
+			Str("user_id", req.UserId).
+			Str("target", req.Target).
+			Msg("failed to events.SetData()")
+		f.revert(ctx, req, repriced)
+		return nil, err
+	}
 
 	if result := f.producerClient.Send(ctx, e); cloudevents.IsUndelivered(result) {
 		err := fmt.Errorf("cloud event message send failed")
@@ -227,6 +389,7 @@ func (f *Frontend) UpdateBuy(ctx context.Context, req *apis.UpdateRequest) (*api
 			Int64("amount", req.Amount).
 			Int64("price", req.Price).
 			Msg("failed to f.producerClient.Send()")
+		f.revert(ctx, req, repriced)
 		return nil, err
 	}
 
@@ -239,6 +402,12 @@ func (f *Frontend) UpdateBuy(ctx context.Context, req *apis.UpdateRequest) (*api
 func (f *Frontend) UpdateSell(ctx context.Context, req *apis.UpdateRequest) (*apis.UpdateResponse, error) {
 	log.Debug().Interface("req", req).Msg("update sell order accepted")
 
+	target, err := f.orderTarget(ctx, req.UserId, req.RequestId, req.Target)
+	if err != nil {
+		return nil, err
+	}
+	req.Target = target
+
 	result := f.redisClient.SetNX(ctx, req.RequestId, 1, f.lockExpireSecond)
 	success, err := result.Result()
 	if err != nil {
@@ -265,6 +434,13 @@ func (f *Frontend) UpdateSell(ctx context.Context, req *apis.UpdateRequest) (*ap
 		return nil, status.Errorf(codes.AlreadyExists, msg)
 	}
 
+	// a refused amend must not keep the order locked
+	repriced, err := f.reprice(ctx, apis.Side_SELL, req)
+	if err != nil {
+		f.unlock(ctx, req.RequestId)
+		return nil, err
+	}
+
 	rid := uuid.New()
 
 	e := cloudevents.NewEvent()
@@ -272,7 +448,16 @@ func (f *Frontend) UpdateSell(ctx context.Context, req *apis.UpdateRequest) (*ap
 	e.SetType(events.UpdateSellType)
 	e.SetTime(time.Now())
 	e.SetSource(events.FrontendSource)
-	_ = e.SetData(cloudevents.ApplicationJSON, req)
+	events.SetPartitionKey(&e, req.Target)
+	if err := events.SetData(&e, f.dataContentType, req); err != nil {
+		log.Error().
+			Err(err).
+			Str("user_id", req.UserId).
+			Str("target", req.Target).
+			Msg("failed to events.SetData()")
+		f.revert(ctx, req, repriced)
+		return nil, err
+	}
 
 	if result := f.producerClient.Send(ctx, e); cloudevents.IsUndelivered(result) {
 		err := fmt.Errorf("cloud event message send failed")
@@ -284,6 +469,7 @@ func (f *Frontend) UpdateSell(ctx context.Context, req *apis.UpdateRequest) (*ap
 			Int64("amount", req.Amount).
 			Int64("price", req.Price).
 			Msg("failed to f.producerClient.Send()")
+		f.revert(ctx, req, repriced)
 		return nil, err
 	}
 
@@ -292,3 +478,13 @@ func (f *Frontend) UpdateSell(ctx context.Context, req *apis.UpdateRequest) (*ap
 
 	return res, nil
 }
+
+// unlock drops the SetNX lock of an order that no request was sent for.
+func (f *Frontend) unlock(ctx context.Context, requestID string) {
+	if err := f.redisClient.Del(ctx, requestID).Err(); err != nil {
+		log.Error().
+			Err(err).
+			Str("request_id", requestID).
+			Msg("failed to f.redisClient.Del()")
+	}
+}
@@ -2,20 +2,22 @@ package frontend_test
 
 import (
 	"context"
-	"fmt"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/atgane/opentd/apis"
 	"github.com/atgane/opentd/pkgs/events"
 	"github.com/atgane/opentd/pkgs/frontend"
 	"github.com/atgane/opentd/pkgs/logging"
+	"github.com/atgane/opentd/pkgs/servetest"
 	cloudevents "github.com/cloudevents/sdk-go/v2"
-	"github.com/nats-io/nats.go"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 var testFrontendScenario = []frontendScenario{
@@ -24,6 +26,8 @@ var testFrontendScenario = []frontendScenario{
 	{"buy  something", testCancel},
 	{"buy  something", testUpdateBuy},
 	{"buy  something", testUpdateSell},
+	{"invalid order", testInvalidOrder},
+	{"stream order updates", testStreamOrderUpdates},
 }
 
 type frontendScenario struct {
@@ -46,18 +50,22 @@ func TestFrontend(t *testing.T) {
 	logging.SetLevel("trace")
 
 	// init test
+	mr := miniredis.RunT(t)
 	ts.conf = frontend.FrontConfig{
-		GRPCPort: 17011,
 		EventConfig: events.EventConfig{
-			EventType: events.NATS,
-			NATSConfig: events.NATSConfig{
-				NATSServer:  "nats://127.0.0.1:4222",
-				Subject:     "some-subject",
-				NATSOptions: []nats.Option{},
+			EventType: events.INMEMORY,
+			InMemoryConfig: events.InMemoryConfig{
+				Topic: "some-subject",
+			},
+		},
+		OrderUpdateConfig: events.EventConfig{
+			EventType: events.INMEMORY,
+			InMemoryConfig: events.InMemoryConfig{
+				Topic: "some-order-update-subject",
 			},
 		},
 		RedisConfig: redis.Options{
-			Addr: "127.0.0.1:6379",
+			Addr: mr.Addr(),
 		},
 		LogLevel:         "trace",
 		LockExpireSecond: 300 * time.Second,
@@ -66,7 +74,7 @@ func TestFrontend(t *testing.T) {
 	f, err := frontend.NewFrontend(ts.conf)
 	require.NoError(t, err)
 	ts.f = f
-	go f.Start()
+	addr := servetest.Serve(t, f)
 
 	ctx := context.Background()
 
@@ -79,7 +87,7 @@ func TestFrontend(t *testing.T) {
 
 	conn, err := grpc.DialContext(
 		ctx,
-		fmt.Sprintf("localhost:%d", ts.conf.GRPCPort),
+		addr,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 	)
 	require.NoError(t, err)
@@ -96,12 +104,15 @@ func TestFrontend(t *testing.T) {
 		} else if e.Type() == events.CancelType {
 			result := &apis.CancelRequest{}
 			require.NoError(t, e.DataAs(result))
+			require.Equal(t, ts.conf.EventConfig.InMemoryConfig.Topic, result.Target)
 		} else if e.Type() == events.UpdateBuyType {
 			result := &apis.UpdateRequest{}
 			require.NoError(t, e.DataAs(result))
+			require.Equal(t, ts.conf.EventConfig.InMemoryConfig.Topic, result.Target)
 		} else if e.Type() == events.UpdateSellType {
 			result := &apis.UpdateRequest{}
 			require.NoError(t, e.DataAs(result))
+			require.Equal(t, ts.conf.EventConfig.InMemoryConfig.Topic, result.Target)
 		}
 
 		ts.callbackChan <- 0
@@ -124,7 +135,7 @@ func testSell(t *testing.T, ts *testState) {
 
 	data := &apis.SellRequest{
 		UserId: "user1",
-		Target: ts.conf.EventConfig.NATSConfig.Subject,
+		Target: ts.conf.EventConfig.InMemoryConfig.Topic,
 		Amount: 1,
 		Price:  30,
 	}
@@ -139,7 +150,7 @@ func testBuy(t *testing.T, ts *testState) {
 
 	data := &apis.BuyRequest{
 		UserId: "user1",
-		Target: ts.conf.EventConfig.NATSConfig.Subject,
+		Target: ts.conf.EventConfig.InMemoryConfig.Topic,
 		Amount: 1,
 		Price:  30,
 	}
@@ -158,6 +169,7 @@ func testCancel(t *testing.T, ts *testState) {
 	data := &apis.CancelRequest{
 		UserId:    "user1",
 		RequestId: rid,
+		Target:    ts.conf.EventConfig.InMemoryConfig.Topic,
 	}
 	_, err := ts.c.Cancel(context.Background(), data)
 	require.NoError(t, err)
@@ -179,6 +191,7 @@ func testUpdateSell(t *testing.T, ts *testState) {
 	data := &apis.UpdateRequest{
 		UserId:    "user1",
 		RequestId: rid,
+		Target:    ts.conf.EventConfig.InMemoryConfig.Topic,
 	}
 	_, err := ts.c.UpdateSell(context.Background(), data)
 	require.NoError(t, err)
@@ -200,6 +213,7 @@ func testUpdateBuy(t *testing.T, ts *testState) {
 	data := &apis.UpdateRequest{
 		UserId:    "user1",
 		RequestId: rid,
+		Target:    ts.conf.EventConfig.InMemoryConfig.Topic,
 	}
 	_, err := ts.c.UpdateBuy(context.Background(), data)
 	require.NoError(t, err)
@@ -211,3 +225,96 @@ func testUpdateBuy(t *testing.T, ts *testState) {
 	require.NoError(t, res.Err())
 	require.Equal(t, "1", res.Val())
 }
+
+func testInvalidOrder(t *testing.T, ts *testState) {
+	t.Helper()
+
+	target := ts.conf.EventConfig.InMemoryConfig.Topic
+	for _, data := range []*apis.BuyRequest{
+		{UserId: "user1", Target: target, Amount: 1, Price: 30, TimeInForce: apis.TimeInForce_GTD},
+		{UserId: "user1", Target: target, Amount: 1, Price: 30, TimeInForce: apis.TimeInForce_GTD, ExpireAt: time.Now().Add(-time.Minute).UnixMilli()},
+		{UserId: "user1", Target: target, Amount: 1, Price: 30, ExpireAt: time.Now().Add(time.Minute).UnixMilli()},
+		{UserId: "user1", Target: target, Amount: 1, OrderType: apis.OrderType_MARKET, TimeInForce: apis.TimeInForce_DAY},
+		{UserId: "user1", Target: target, Amount: 1, Price: 30, TimeInForce: apis.TimeInForce(42)},
+		{UserId: "user1", Target: target, Amount: 1, OrderType: apis.OrderType_STOP},
+		{UserId: "user1", Target: target, Amount: 1, Price: 30, StopPrice: 25},
+		{UserId: "user1", Target: target, Amount: 1, OrderType: apis.OrderType_MARKET, DisplayAmount: 1},
+		{UserId: "user1", Target: target, Amount: 1, Price: 30, PostOnly: true, TimeInForce: apis.TimeInForce_IOC},
+		{UserId: "user1", Target: target, Amount: 1, OrderType: apis.OrderType_MARKET, TimeInForce: apis.TimeInForce_GTD, ExpireAt: time.Now().Add(time.Minute).UnixMilli()},
+	} {
+		_, err := ts.c.Buy(context.Background(), data)
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	// cancels and updates without a target need a known order to take it from
+	_, err := ts.c.Cancel(context.Background(), &apis.CancelRequest{UserId: "user1", RequestId: "0001"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	_, err = ts.c.UpdateBuy(context.Background(), &apis.UpdateRequest{UserId: "user1", RequestId: "0001", Amount: 1, Price: 30})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	_, err = ts.c.UpdateSell(context.Background(), &apis.UpdateRequest{UserId: "user1", RequestId: "0001", Amount: 1, Price: 30})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	data := &apis.BuyRequest{
+		UserId:      "user1",
+		Target:      target,
+		Amount:      1,
+		Price:       30,
+		TimeInForce: apis.TimeInForce_GTD,
+		ExpireAt:    time.Now().Add(time.Minute).UnixMilli(),
+	}
+	res, err := ts.c.Buy(context.Background(), data)
+	require.NoError(t, err)
+
+	require.Equal(t, 0, <-ts.callbackChan)
+
+	// the cancel is keyed by the target the order was placed with
+	defer ts.redisClient.Del(context.Background(), res.RequestId)
+	_, err = ts.c.Cancel(context.Background(), &apis.CancelRequest{UserId: "user1", RequestId: res.RequestId})
+	require.NoError(t, err)
+
+	require.Equal(t, 0, <-ts.callbackChan)
+
+	// orders of other users are not looked up
+	_, err = ts.c.Cancel(context.Background(), &apis.CancelRequest{UserId: "user2", RequestId: res.RequestId})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func testStreamOrderUpdates(t *testing.T, ts *testState) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := ts.c.StreamOrderUpdates(ctx, &apis.StreamOrderUpdatesRequest{UserId: "user1"})
+	require.NoError(t, err)
+	_, err = stream.Header()
+	require.NoError(t, err)
+
+	producer, err := events.NewProducerEvent(ts.conf.OrderUpdateConfig)
+	require.NoError(t, err)
+	for _, user := range []string{"user2", "user1"} {
+		e := cloudevents.NewEvent()
+		e.SetID(user + "-order-1")
+		e.SetType(events.OrderUpdateType)
+		e.SetSource(events.DealerSource)
+		u := &apis.OrderUpdate{Kind: apis.OrderUpdateKind_UPDATE_FILLED, Order: &apis.Order{RequestId: user + "-order", UserId: user}}
+		require.NoError(t, events.SetData(&e, cloudevents.ApplicationJSON, u))
+		require.False(t, cloudevents.IsUndelivered(producer.Send(ctx, e)))
+	}
+
+	// updates of other users are never delivered
+	u, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "user1-order", u.Order.RequestId)
+	require.Equal(t, apis.OrderUpdateKind_UPDATE_FILLED, u.Kind)
+
+	invalid, err := ts.c.StreamOrderUpdates(ctx, &apis.StreamOrderUpdatesRequest{})
+	require.NoError(t, err)
+	_, err = invalid.Recv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestFrontendDataContentType(t *testing.T) {
+	_, err := frontend.NewFrontend(frontend.FrontConfig{DataContentType: "text/xml"})
+	require.ErrorContains(t, err, "unsupported data content type")
+}
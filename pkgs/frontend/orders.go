@@ -0,0 +1,124 @@
+package frontend
+
+import (
+	"context"
+	"errors"
+
+	"github.com/atgane/opentd/apis"
+	"github.com/atgane/opentd/pkgs/orders"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (f *Frontend) GetOrder(ctx context.Context, req *apis.GetOrderRequest) (*apis.Order, error) {
+	log.Debug().Interface("req", req).Msg("get order accepted")
+
+	if req.UserId == "" || req.RequestId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "user_id and request_id are required")
+	}
+
+	o, err := f.orders.Get(ctx, req.RequestId)
+	// orders of other users are not disclosed
+	if errors.Is(err, orders.ErrNotFound) || (err == nil && o.UserId != req.UserId) {
+		return nil, status.Errorf(codes.NotFound, "order %s not found", req.RequestId)
+	}
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("user_id", req.UserId).
+			Str("request_id", req.RequestId).
+			Msg("failed to f.orders.Get()")
+		return nil, err
+	}
+	return o, nil
+}
+
+func (f *Frontend) ListOrders(ctx context.Context, req *apis.ListOrdersRequest) (*apis.ListOrdersResponse, error) {
+	log.Debug().Interface("req", req).Msg("list orders accepted")
+
+	if req.UserId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "user_id is required")
+	}
+	if req.PageSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "page_size must not be negative")
+	}
+
+	res, err := f.orders.List(ctx, req)
+	if errors.Is(err, orders.ErrInvalidPageToken) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("user_id", req.UserId).
+			Str("target", req.Target).
+			Msg("failed to f.orders.List()")
+		return nil, err
+	}
+	return res, nil
+}
+
+// orderTarget returns the target of the order a cancel or an update refers
+// to. The request is keyed by that target so it cannot overtake its order on a
+// partitioned broker, clients that leave it out get the one the order was
+// placed with.
+func (f *Frontend) orderTarget(ctx context.Context, userID, requestID, target string) (string, error) {
+	if target != "" {
+		return target, nil
+	}
+
+	o, err := f.orders.Get(ctx, requestID)
+	if errors.Is(err, orders.ErrNotFound) || (err == nil && o.UserId != userID) {
+		return "", status.Errorf(codes.NotFound, "order %s not found", requestID)
+	}
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("user_id", userID).
+			Str("request_id", requestID).
+			Msg("failed to f.orders.Get()")
+		return "", err
+	}
+	return o.Target, nil
+}
+
+// savePending records the order of e before e is sent, so GetOrder knows the
+// request id as soon as it is returned.
+func (f *Frontend) savePending(ctx context.Context, e cloudevents.Event, side apis.Side, req orderRequest) error {
+	o := new(apis.Order)
+	o.RequestId = e.ID()
+	o.UserId = req.GetUserId()
+	o.Target = req.GetTarget()
+	o.Side = side
+	o.OrderType = req.GetOrderType()
+	o.Status = apis.OrderLifecycle_ORDER_PENDING
+	o.Price = req.GetPrice()
+	o.StopPrice = req.GetStopPrice()
+	o.Amount = req.GetAmount()
+	o.RemainingAmount = req.GetAmount()
+	o.CreatedAt = e.Time().UnixMilli()
+	o.UpdatedAt = o.CreatedAt
+
+	if err := f.orders.SavePending(ctx, o); err != nil {
+		log.Error().
+			Err(err).
+			Str("user_id", o.UserId).
+			Str("request_id", o.RequestId).
+			Msg("failed to f.orders.SavePending()")
+		return err
+	}
+	return nil
+}
+
+// deletePending removes the record of an order whose event could not be sent.
+// A failure only leaves a stale PENDING record behind, so it is logged.
+func (f *Frontend) deletePending(ctx context.Context, e cloudevents.Event) {
+	if err := f.orders.DeletePending(ctx, e.ID()); err != nil {
+		log.Error().
+			Err(err).
+			Str("request_id", e.ID()).
+			Msg("failed to f.orders.DeletePending()")
+	}
+}
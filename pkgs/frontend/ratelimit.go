@@ -0,0 +1,218 @@
+package frontend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const defaultRateLimitKeyPrefix = "opentd:ratelimit"
+
+// RetryAfterTrailer tells a rate limited client how many milliseconds to wait
+// before its next request is let through.
+const RetryAfterTrailer = "retry-after-ms"
+
+type RateLimit struct {
+	// Rate is how many requests a second the bucket refills.
+	Rate float64
+	// Burst is the size of the bucket.
+	Burst int
+}
+
+// RateLimitConfig limits the requests of every user with token buckets kept in
+// redis, so every frontend replica draws from the same buckets.
+type RateLimitConfig struct {
+	Enabled   bool
+	KeyPrefix string
+	// Limits is the limit of each rpc by method name, e.g. "Buy". Methods
+	// without a limit are not limited.
+	Limits map[string]RateLimit
+	// Tiers override Limits for the users of a tier. The tier of a user is
+	// read from the redis hash "<KeyPrefix>:tiers".
+	Tiers map[string]map[string]RateLimit
+}
+
+// takeScript refills the bucket for the time passed since it was last used and
+// takes a token from it. It returns 0 when a token was taken, or else the
+// milliseconds until the next one. Time is read from redis, so replicas with
+// skewed clocks share the buckets fairly.
+var takeScript = redis.NewScript(`
+local rate, burst = tonumber(ARGV[1]), tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'at')
+local tokens = tonumber(bucket[1]) or burst
+local at = tonumber(bucket[2]) or now
+if now > at then
+	tokens = math.min(burst, tokens + (now - at) * rate / 1000)
+	at = now
+end
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+else
+	wait = math.ceil((1 - tokens) * 1000 / rate)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'at', tostring(at))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return wait
+`)
+
+type rateLimiter struct {
+	redisClient *redis.Client
+	prefix      string
+	limits      map[string]RateLimit
+	tiers       map[string]map[string]RateLimit
+}
+
+func (conf RateLimitConfig) validate() error {
+	for method, limit := range conf.Limits {
+		if err := limit.validate(); err != nil {
+			return fmt.Errorf("rate limit of %s: %w", method, err)
+		}
+	}
+	for tier, limits := range conf.Tiers {
+		for method, limit := range limits {
+			if err := limit.validate(); err != nil {
+				return fmt.Errorf("rate limit of %s in tier %s: %w", method, tier, err)
+			}
+		}
+	}
+	return nil
+}
+
+func newRateLimiter(conf RateLimitConfig, redisClient *redis.Client) *rateLimiter {
+	if !conf.Enabled {
+		return nil
+	}
+
+	r := new(rateLimiter)
+	r.redisClient = redisClient
+	r.prefix = conf.KeyPrefix
+	if r.prefix == "" {
+		r.prefix = defaultRateLimitKeyPrefix
+	}
+	r.limits = conf.Limits
+	r.tiers = conf.Tiers
+	return r
+}
+
+func (l RateLimit) validate() error {
+	if l.Rate <= 0 || l.Burst < 1 {
+		return fmt.Errorf("rate must be positive and burst at least 1")
+	}
+	return nil
+}
+
+// limit returns the limit of method for a user, and false when the method is
+// not limited.
+func (r *rateLimiter) limit(ctx context.Context, method, userID string) (RateLimit, bool, error) {
+	if len(r.tiers) > 0 {
+		tier, err := r.redisClient.HGet(ctx, r.prefix+":tiers", userID).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return RateLimit{}, false, err
+		}
+		if limit, ok := r.tiers[tier][method]; ok {
+			return limit, true, nil
+		}
+	}
+
+	limit, ok := r.limits[method]
+	return limit, ok, nil
+}
+
+// limited reports whether method has a limit for any user.
+func (r *rateLimiter) limited(method string) bool {
+	if _, ok := r.limits[method]; ok {
+		return true
+	}
+	for _, limits := range r.tiers {
+		if _, ok := limits[method]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// check takes a token of the user of req for method. Requests are let through
+// when redis fails, so the limiter never takes the frontend down with it, but
+// limited methods are refused without a user to draw the token from.
+func (r *rateLimiter) check(ctx context.Context, fullMethod string, req any, setTrailer func(metadata.MD)) error {
+	u, ok := req.(interface{ GetUserId() string })
+	if !ok {
+		return nil
+	}
+	method := path.Base(fullMethod)
+	if u.GetUserId() == "" {
+		if r.limited(method) {
+			return status.Errorf(codes.InvalidArgument, "user_id is required")
+		}
+		return nil
+	}
+
+	limit, ok, err := r.limit(ctx, method, u.GetUserId())
+	if err != nil {
+		log.Error().Err(err).Str("user_id", u.GetUserId()).Msg("failed to r.limit()")
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+
+	key := r.prefix + ":" + method + ":" + u.GetUserId()
+	wait, err := takeScript.Run(ctx, r.redisClient, []string{key}, limit.Rate, limit.Burst).Int64()
+	if err != nil {
+		log.Error().Err(err).Str("user_id", u.GetUserId()).Str("method", method).Msg("failed to takeScript.Run()")
+		return nil
+	}
+	if wait == 0 {
+		return nil
+	}
+
+	setTrailer(metadata.Pairs(RetryAfterTrailer, strconv.FormatInt(wait, 10)))
+	return status.Errorf(codes.ResourceExhausted, "rate limit of %s exceeded, retry after %dms", method, wait)
+}
+
+func (r *rateLimiter) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	err := r.check(ctx, info.FullMethod, req, func(md metadata.MD) {
+		_ = grpc.SetTrailer(ctx, md)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (r *rateLimiter) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &limitedStream{ServerStream: ss, limiter: r, method: info.FullMethod})
+}
+
+// limitedStream checks the limit once the request of a server stream is
+// received, since that is where the user id is.
+type limitedStream struct {
+	grpc.ServerStream
+	limiter *rateLimiter
+	method  string
+	checked bool
+}
+
+func (s *limitedStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if s.checked {
+		return nil
+	}
+
+	s.checked = true
+	return s.limiter.check(s.Context(), s.method, m, s.ServerStream.SetTrailer)
+}
@@ -0,0 +1,103 @@
+package frontend_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/atgane/opentd/apis"
+	"github.com/atgane/opentd/pkgs/events"
+	"github.com/atgane/opentd/pkgs/frontend"
+	"github.com/atgane/opentd/pkgs/servetest"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimit(t *testing.T) {
+	mr := miniredis.RunT(t)
+	conf := frontend.FrontConfig{
+		EventConfig: events.EventConfig{
+			EventType:      events.INMEMORY,
+			InMemoryConfig: events.InMemoryConfig{Topic: "ratelimit-orders"},
+		},
+		OrderUpdateConfig: events.EventConfig{
+			EventType:      events.INMEMORY,
+			InMemoryConfig: events.InMemoryConfig{Topic: "ratelimit-order-updates"},
+		},
+		RateLimitConfig: frontend.RateLimitConfig{
+			Enabled: true,
+			Limits: map[string]frontend.RateLimit{
+				"Buy":                {Rate: 0.01, Burst: 2},
+				"StreamOrderUpdates": {Rate: 0.01, Burst: 1},
+			},
+			Tiers: map[string]map[string]frontend.RateLimit{
+				"vip": {"Buy": {Rate: 0.01, Burst: 4}},
+			},
+		},
+		RedisConfig:      redis.Options{Addr: mr.Addr()},
+		LockExpireSecond: time.Minute,
+	}
+	invalid := conf
+	invalid.RateLimitConfig.Limits = map[string]frontend.RateLimit{"Buy": {Rate: 1}}
+	_, err := frontend.NewFrontend(invalid)
+	require.Error(t, err)
+
+	f, err := frontend.NewFrontend(conf)
+	require.NoError(t, err)
+	addr := servetest.Serve(t, f)
+
+	ctx := context.Background()
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	c := apis.NewFrontendClient(conn)
+
+	buys := func(user string, n int) {
+		t.Helper()
+		for i := 0; i < n; i++ {
+			_, err := c.Buy(ctx, &apis.BuyRequest{UserId: user, Target: "t", Amount: 1, Price: 10})
+			require.NoError(t, err)
+		}
+	}
+
+	buys("user1", 2)
+	var trailer metadata.MD
+	_, err = c.Buy(ctx, &apis.BuyRequest{UserId: "user1", Target: "t", Amount: 1, Price: 10}, grpc.Trailer(&trailer))
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.Len(t, trailer.Get(frontend.RetryAfterTrailer), 1)
+	wait, err := strconv.Atoi(trailer.Get(frontend.RetryAfterTrailer)[0])
+	require.NoError(t, err)
+	require.Greater(t, wait, 0)
+
+	// limited methods need a user to draw from
+	_, err = c.Buy(ctx, &apis.BuyRequest{Target: "t", Amount: 1, Price: 10})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// buckets are per user and methods without a limit are not limited
+	buys("user2", 2)
+	_, err = c.GetOrder(ctx, &apis.GetOrderRequest{UserId: "user1", RequestId: "missing"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	mr.HSet("opentd:ratelimit:tiers", "user3", "vip")
+	buys("user3", 4)
+	_, err = c.Buy(ctx, &apis.BuyRequest{UserId: "user3", Target: "t", Amount: 1, Price: 10})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// server streams are limited once their request is received
+	stream, err := c.StreamOrderUpdates(ctx, &apis.StreamOrderUpdatesRequest{UserId: "user1"})
+	require.NoError(t, err)
+	_, err = stream.Header()
+	require.NoError(t, err)
+	stream, err = c.StreamOrderUpdates(ctx, &apis.StreamOrderUpdatesRequest{UserId: "user1"})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.NotEmpty(t, stream.Trailer().Get(frontend.RetryAfterTrailer))
+}
@@ -0,0 +1,388 @@
+package frontend
+
+import (
+	"context"
+	"math"
+	"strconv"
+
+	"github.com/atgane/opentd/apis"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultBalanceKeyPrefix = "opentd:balance"
+	defaultCashAsset        = "cash"
+)
+
+// RiskConfig enables pre-trade balance checks. Balances are redis hashes keyed
+// by user, holding the available amount of every asset in a field named after
+// the asset and the amount held by open orders in "<asset>:held". Buys hold
+// cash, sells hold the target itself.
+type RiskConfig struct {
+	Enabled   bool
+	KeyPrefix string
+	CashAsset string
+}
+
+// holdScript moves amount from available to held if enough is available and
+// records the hold under the request id, all or nothing. unit is what the hold
+// covers per unit of the order, so settlement can give back what a fill at a
+// better price did not spend, and ordered is the order amount it covers.
+// Settlement counts the units it settled and released in "filled" and
+// "cancelled", so the hold always covers unit * (ordered - cancelled - filled).
+var holdScript = redis.NewScript(`
+local available = tonumber(redis.call('HGET', KEYS[1], ARGV[1]) or '0')
+local amount = tonumber(ARGV[2])
+if available < amount then
+	return 0
+end
+redis.call('HINCRBY', KEYS[1], ARGV[1], -amount)
+redis.call('HINCRBY', KEYS[1], ARGV[1] .. ':held', amount)
+redis.call('HSET', KEYS[2], 'user', ARGV[3], 'asset', ARGV[1], 'amount', amount, 'unit', ARGV[4], 'ordered', ARGV[5])
+return 1
+`)
+
+// repriceScript tops the hold of an order up for an amend to ARGV[3] units at
+// ARGV[2] a unit, all or nothing. Fills that are not settled yet cannot be told
+// apart from the open amount here, so the hold keeps the higher unit and what
+// it covers only grows; the update of the amended order gives back the rest.
+// Orders without a hold of the user are left to the engine. It returns whether
+// the hold was topped up, the top up, and the unit and ordered amount before
+// and after, for revertScript.
+var repriceScript = redis.NewScript(`
+local hold = redis.call('HMGET', KEYS[2], 'user', 'asset', 'amount', 'unit', 'ordered', 'cancelled', 'filled')
+if hold[1] ~= ARGV[1] or not hold[5] then
+	return {1, 0}
+end
+local amount, unit, ordered = tonumber(hold[3]), math.max(tonumber(hold[4]), tonumber(ARGV[2])), tonumber(hold[5])
+local done = tonumber(hold[6] or '0') + tonumber(hold[7] or '0')
+ordered = ordered + math.max(0, tonumber(ARGV[3]) - (ordered - done))
+local top = math.max(0, unit * (ordered - done) - amount)
+if top > 0 then
+	if tonumber(redis.call('HGET', KEYS[1], hold[2]) or '0') < top then
+		return {0, 0}
+	end
+	redis.call('HINCRBY', KEYS[1], hold[2], -top)
+	redis.call('HINCRBY', KEYS[1], hold[2] .. ':held', top)
+	redis.call('HINCRBY', KEYS[2], 'amount', top)
+end
+redis.call('HSET', KEYS[2], 'unit', unit, 'ordered', ordered)
+return {1, top, tonumber(hold[4]), tonumber(hold[5]), unit, ordered}
+`)
+
+// revertScript takes back a reprice whose amend never reached the engine. The
+// unit and ordered amount are only restored while no later reprice or update
+// changed them, and the top up only goes back as far as the hold exceeds what
+// the restored order needs.
+var revertScript = redis.NewScript(`
+local hold = redis.call('HMGET', KEYS[2], 'asset', 'amount', 'unit', 'ordered', 'cancelled', 'filled')
+if not hold[1] or tonumber(hold[3]) ~= tonumber(ARGV[4]) or tonumber(hold[4]) ~= tonumber(ARGV[5]) then
+	return 0
+end
+local done = tonumber(hold[5] or '0') + tonumber(hold[6] or '0')
+local excess = math.min(tonumber(ARGV[1]), tonumber(hold[2]) - tonumber(ARGV[2]) * (tonumber(ARGV[3]) - done))
+if excess > 0 then
+	redis.call('HINCRBY', KEYS[1], hold[1] .. ':held', -excess)
+	redis.call('HINCRBY', KEYS[1], hold[1], excess)
+	redis.call('HINCRBY', KEYS[2], 'amount', -excess)
+end
+redis.call('HSET', KEYS[2], 'unit', ARGV[2], 'ordered', ARGV[3])
+return 1
+`)
+
+// reconcileScript brings the hold of an order in line with an update of it,
+// once per revision. ARGV holds the revision, whether the order was rejected,
+// its amount, cancelled and filled amounts, its price when it was amended and
+// whether its amount is final. A rejected order never reaches a book and gives
+// its hold back whole; otherwise what the hold covers beyond the units still
+// open or waiting for settlement goes back, and an amended order takes what is
+// available towards what it is short.
+var reconcileScript = redis.NewScript(`
+local hold = redis.call('HMGET', KEYS[2], 'asset', 'amount', 'unit', 'ordered', 'cancelled', 'filled', 'revision')
+if not hold[1] or tonumber(hold[7] or '0') >= tonumber(ARGV[1]) then
+	return 0
+end
+local asset, amount, unit = hold[1], tonumber(hold[2]), tonumber(hold[3])
+local price = tonumber(ARGV[6])
+local target = 0
+if ARGV[2] ~= '1' then
+	if not hold[4] then
+		return 0
+	end
+	local ordered = math.max(tonumber(hold[4]), tonumber(ARGV[3]))
+	if ARGV[7] == '1' then
+		ordered = tonumber(ARGV[3])
+	end
+	local cancelled = math.max(tonumber(hold[5] or '0'), tonumber(ARGV[4]))
+	local filled = tonumber(hold[6] or '0')
+	if price > 0 then
+		if tonumber(ARGV[5]) > filled then
+			unit = math.max(unit, price)
+		else
+			unit = price
+		end
+	end
+	target = math.max(0, unit * (ordered - cancelled - filled))
+	redis.call('HSET', KEYS[2], 'unit', unit, 'ordered', ordered, 'cancelled', cancelled, 'revision', ARGV[1])
+end
+local excess = amount - target
+if excess < 0 then
+	excess = 0
+	if price > 0 then
+		excess = -math.min(target - amount, math.max(0, tonumber(redis.call('HGET', KEYS[1], asset) or '0')))
+	end
+end
+if excess ~= 0 then
+	redis.call('HINCRBY', KEYS[1], asset .. ':held', -excess)
+	redis.call('HINCRBY', KEYS[1], asset, excess)
+	redis.call('HINCRBY', KEYS[2], 'amount', -excess)
+end
+if target == 0 then
+	redis.call('DEL', KEYS[2])
+end
+return 1
+`)
+
+// releaseScript gives a recorded hold back to the available balance.
+var releaseScript = redis.NewScript(`
+local hold = redis.call('HMGET', KEYS[2], 'asset', 'amount')
+if not hold[1] then
+	return 0
+end
+redis.call('HINCRBY', KEYS[1], hold[1] .. ':held', -tonumber(hold[2]))
+redis.call('HINCRBY', KEYS[1], hold[1], tonumber(hold[2]))
+redis.call('DEL', KEYS[2])
+return 1
+`)
+
+type riskChecker struct {
+	redisClient *redis.Client
+	prefix      string
+	cashAsset   string
+}
+
+func newRiskChecker(conf RiskConfig, redisClient *redis.Client) *riskChecker {
+	if !conf.Enabled {
+		return nil
+	}
+
+	r := new(riskChecker)
+	r.redisClient = redisClient
+	r.prefix = conf.KeyPrefix
+	if r.prefix == "" {
+		r.prefix = defaultBalanceKeyPrefix
+	}
+	r.cashAsset = conf.CashAsset
+	if r.cashAsset == "" {
+		r.cashAsset = defaultCashAsset
+	}
+	return r
+}
+
+func (r *riskChecker) balanceKey(userID string) string {
+	return r.prefix + ":" + userID
+}
+
+func (r *riskChecker) holdKey(requestID string) string {
+	return r.prefix + ":hold:" + requestID
+}
+
+// requirement returns the asset an order must hold, how much of it per unit
+// of the order and in total. Market buys have no price, so their protection
+// price bounds the cost.
+func (r *riskChecker) requirement(side apis.Side, req orderRequest) (string, int64, int64, error) {
+	if side == apis.Side_SELL {
+		return req.GetTarget(), 1, req.GetAmount(), nil
+	}
+
+	price := req.GetPrice()
+	if orderType := req.GetOrderType(); orderType == apis.OrderType_MARKET || orderType == apis.OrderType_STOP {
+		price = req.GetProtectionPrice()
+	}
+	if price <= 0 || req.GetAmount() <= 0 {
+		return "", 0, 0, status.Errorf(codes.InvalidArgument, "buy orders need a price, or a protection_price for market orders, to check the cash balance")
+	}
+	if req.GetAmount() > math.MaxInt64/price {
+		return "", 0, 0, status.Errorf(codes.InvalidArgument, "order value overflows")
+	}
+	return r.cashAsset, price, price * req.GetAmount(), nil
+}
+
+// hold reserves what the order needs, or fails with FailedPrecondition.
+func (r *riskChecker) hold(ctx context.Context, requestID string, side apis.Side, req orderRequest) error {
+	asset, unit, amount, err := r.requirement(side, req)
+	if err != nil {
+		return err
+	}
+
+	keys := []string{r.balanceKey(req.GetUserId()), r.holdKey(requestID)}
+	ok, err := holdScript.Run(ctx, r.redisClient, keys, asset, strconv.FormatInt(amount, 10), req.GetUserId(), strconv.FormatInt(unit, 10), req.GetAmount()).Int()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return status.Errorf(codes.FailedPrecondition, "insufficient %s balance, %d required", asset, amount)
+	}
+	return nil
+}
+
+// release gives the hold of an order that never reached the engine back.
+func (r *riskChecker) release(ctx context.Context, requestID, userID string) error {
+	keys := []string{r.balanceKey(userID), r.holdKey(requestID)}
+	return releaseScript.Run(ctx, r.redisClient, keys).Err()
+}
+
+// repricing is what reprice changed in the hold of an order, so that revert
+// can take it back.
+type repricing struct {
+	top         int64
+	prevUnit    int64
+	prevOrdered int64
+	unit        int64
+	ordered     int64
+}
+
+// reprice tops the hold of the order req amends up to the amended amount and
+// price, or fails with FailedPrecondition. It returns nil when the hold was
+// left alone.
+func (r *riskChecker) reprice(ctx context.Context, side apis.Side, req *apis.UpdateRequest) (*repricing, error) {
+	if req.Price <= 0 || req.Amount <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "amount and price must be positive")
+	}
+	unit := int64(1)
+	if side == apis.Side_BUY {
+		unit = req.Price
+	}
+	if req.Amount > math.MaxInt64/unit {
+		return nil, status.Errorf(codes.InvalidArgument, "order value overflows")
+	}
+
+	keys := []string{r.balanceKey(req.UserId), r.holdKey(req.RequestId)}
+	res, err := repriceScript.Run(ctx, r.redisClient, keys, req.UserId, unit, req.Amount).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	if res[0] == 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "insufficient balance to amend the order to %d at %d", req.Amount, req.Price)
+	}
+	if len(res) < 6 {
+		return nil, nil
+	}
+	return &repricing{top: res[1], prevUnit: res[2], prevOrdered: res[3], unit: res[4], ordered: res[5]}, nil
+}
+
+// revert takes back what reprice changed for an amend that was not sent.
+func (r *riskChecker) revert(ctx context.Context, req *apis.UpdateRequest, p *repricing) error {
+	keys := []string{r.balanceKey(req.UserId), r.holdKey(req.RequestId)}
+	return revertScript.Run(ctx, r.redisClient, keys, p.top, p.prevUnit, p.prevOrdered, p.unit, p.ordered).Err()
+}
+
+// reconcile gives back what the hold of the order of u no longer needs. Only
+// amends change the price a buy holds per unit. A refused amend leaves the order
+// as it was, which the hold then follows like an amend.
+func (r *riskChecker) reconcile(ctx context.Context, u *apis.OrderUpdate) error {
+	o := u.Order
+	if o == nil {
+		return nil
+	}
+	rejected := o.Status == apis.OrderLifecycle_ORDER_REJECTED
+	amended := u.Kind == apis.OrderUpdateKind_UPDATE_AMENDED || (u.Kind == apis.OrderUpdateKind_UPDATE_REJECTED && !rejected)
+	var price int64
+	if amended && o.Side == apis.Side_BUY {
+		price = o.Price
+	}
+	final := amended || o.RemainingAmount == 0
+
+	keys := []string{r.balanceKey(o.UserId), r.holdKey(o.RequestId)}
+	args := []any{o.Revision, flag(rejected), o.Amount, o.CancelledAmount, o.FilledAmount, price, flag(final)}
+	return reconcileScript.Run(ctx, r.redisClient, keys, args...).Err()
+}
+
+func flag(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// hold runs the risk check of the order of e when risk checks are enabled.
+func (f *Frontend) hold(ctx context.Context, e cloudevents.Event, side apis.Side, req orderRequest) error {
+	if f.risk == nil {
+		return nil
+	}
+
+	if err := f.risk.hold(ctx, e.ID(), side, req); err != nil {
+		log.Warn().
+			Err(err).
+			Str("user_id", req.GetUserId()).
+			Str("request_id", e.ID()).
+			Msg("order failed the risk check")
+		return err
+	}
+	return nil
+}
+
+func (f *Frontend) release(ctx context.Context, e cloudevents.Event, req orderRequest) {
+	if f.risk == nil {
+		return
+	}
+
+	if err := f.risk.release(ctx, e.ID(), req.GetUserId()); err != nil {
+		log.Error().
+			Err(err).
+			Str("user_id", req.GetUserId()).
+			Str("request_id", e.ID()).
+			Msg("failed to f.risk.release()")
+	}
+}
+
+// reprice re-prices the hold of an amended order when risk checks are enabled.
+func (f *Frontend) reprice(ctx context.Context, side apis.Side, req *apis.UpdateRequest) (*repricing, error) {
+	if f.risk == nil {
+		return nil, nil
+	}
+
+	p, err := f.risk.reprice(ctx, side, req)
+	if err != nil {
+		log.Warn().
+			Err(err).
+			Str("user_id", req.UserId).
+			Str("request_id", req.RequestId).
+			Msg("amend failed the risk check")
+		return nil, err
+	}
+	return p, nil
+}
+
+func (f *Frontend) revert(ctx context.Context, req *apis.UpdateRequest, p *repricing) {
+	if f.risk == nil || p == nil {
+		return
+	}
+
+	// the request context may be what ended the send
+	if err := f.risk.revert(context.WithoutCancel(ctx), req, p); err != nil {
+		log.Error().
+			Err(err).
+			Str("user_id", req.UserId).
+			Str("request_id", req.RequestId).
+			Msg("failed to f.risk.revert()")
+	}
+}
+
+func (f *Frontend) reconcile(ctx context.Context, u *apis.OrderUpdate) {
+	if f.risk == nil {
+		return
+	}
+
+	if err := f.risk.reconcile(ctx, u); err != nil {
+		log.Error().
+			Err(err).
+			Str("user_id", u.Order.GetUserId()).
+			Str("request_id", u.Order.GetRequestId()).
+			Msg("failed to f.risk.reconcile()")
+	}
+}
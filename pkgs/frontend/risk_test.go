@@ -0,0 +1,210 @@
+package frontend_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/atgane/opentd/apis"
+	"github.com/atgane/opentd/pkgs/events"
+	"github.com/atgane/opentd/pkgs/frontend"
+	"github.com/atgane/opentd/pkgs/servetest"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func TestRiskCheck(t *testing.T) {
+	mr := miniredis.RunT(t)
+	conf := frontend.FrontConfig{
+		EventConfig: events.EventConfig{
+			EventType:      events.INMEMORY,
+			InMemoryConfig: events.InMemoryConfig{Topic: "risk-orders"},
+		},
+		OrderUpdateConfig: events.EventConfig{
+			EventType:      events.INMEMORY,
+			InMemoryConfig: events.InMemoryConfig{Topic: "risk-order-updates"},
+		},
+		RiskConfig:       frontend.RiskConfig{Enabled: true},
+		RedisConfig:      redis.Options{Addr: mr.Addr()},
+		LockExpireSecond: time.Minute,
+	}
+	f, err := frontend.NewFrontend(conf)
+	require.NoError(t, err)
+	addr := servetest.Serve(t, f)
+
+	ctx := context.Background()
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	c := apis.NewFrontendClient(conn)
+
+	mr.HSet("opentd:balance:user1", "cash", "100", "t", "3")
+	balance := func(field string) string {
+		return mr.HGet("opentd:balance:user1", field)
+	}
+
+	res, err := c.Buy(ctx, &apis.BuyRequest{UserId: "user1", Target: "t", Amount: 3, Price: 30})
+	require.NoError(t, err)
+	require.Equal(t, "10", balance("cash"))
+	require.Equal(t, "90", balance("cash:held"))
+	require.Equal(t, "90", mr.HGet("opentd:balance:hold:"+res.RequestId, "amount"))
+	require.Equal(t, "30", mr.HGet("opentd:balance:hold:"+res.RequestId, "unit"))
+
+	// nothing is held for a rejected order
+	_, err = c.Buy(ctx, &apis.BuyRequest{UserId: "user1", Target: "t", Amount: 1, Price: 11})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	require.Equal(t, "10", balance("cash"))
+
+	// market buys are bounded by their protection price
+	_, err = c.Buy(ctx, &apis.BuyRequest{UserId: "user1", Target: "t", Amount: 1, OrderType: apis.OrderType_MARKET})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	market, err := c.Buy(ctx, &apis.BuyRequest{UserId: "user1", Target: "t", Amount: 1, OrderType: apis.OrderType_MARKET, ProtectionPrice: 10})
+	require.NoError(t, err)
+	require.Equal(t, "0", balance("cash"))
+
+	_, err = c.Sell(ctx, &apis.SellRequest{UserId: "user1", Target: "t", Amount: 4, Price: 30})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	_, err = c.Sell(ctx, &apis.SellRequest{UserId: "user1", Target: "t", Amount: 3, Price: 30})
+	require.NoError(t, err)
+	require.Equal(t, "0", balance("t"))
+	require.Equal(t, "3", balance("t:held"))
+
+	_, err = c.Sell(ctx, &apis.SellRequest{UserId: "user2", Target: "t", Amount: 1, Price: 30})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// an amend above the balance fails and the hold is topped up otherwise
+	mr.HSet("opentd:balance:user1", "cash", "10")
+	amend := &apis.UpdateRequest{UserId: "user1", RequestId: res.RequestId, Target: "t", Amount: 4, Price: 30}
+	_, err = c.UpdateBuy(ctx, amend)
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	require.Equal(t, "10", balance("cash"))
+	amend.Amount, amend.Price = 3, 33
+	_, err = c.UpdateBuy(ctx, amend)
+	require.NoError(t, err)
+	require.Equal(t, "1", balance("cash"))
+	require.Equal(t, "99", mr.HGet("opentd:balance:hold:"+res.RequestId, "amount"))
+
+	// updates of the orders give back what their holds no longer cover
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.StreamOrderUpdates(streamCtx, &apis.StreamOrderUpdatesRequest{UserId: "user1"})
+	require.NoError(t, err)
+	_, err = stream.Header()
+	require.NoError(t, err)
+	producer, err := events.NewProducerEvent(conf.OrderUpdateConfig)
+	require.NoError(t, err)
+	update := func(kind apis.OrderUpdateKind, o *apis.Order) {
+		t.Helper()
+		e := cloudevents.NewEvent()
+		e.SetID(fmt.Sprintf("%s-%d", o.RequestId, o.Revision))
+		e.SetType(events.OrderUpdateType)
+		e.SetSource(events.DealerSource)
+		o.UserId, o.Side = "user1", apis.Side_BUY
+		require.NoError(t, events.SetData(&e, cloudevents.ApplicationJSON, &apis.OrderUpdate{Kind: kind, Order: o}))
+		require.False(t, cloudevents.IsUndelivered(producer.Send(ctx, e)))
+		_, err := stream.Recv()
+		require.NoError(t, err)
+	}
+
+	// a refused amend gives the top up back and keeps the order's hold
+	update(apis.OrderUpdateKind_UPDATE_REJECTED, &apis.Order{RequestId: res.RequestId, Status: apis.OrderLifecycle_ORDER_OPEN, Price: 30, Amount: 3, RemainingAmount: 3, RejectReason: apis.RejectReason_POST_ONLY_WOULD_CROSS, Revision: 2})
+	require.Equal(t, "10", balance("cash"))
+	require.Equal(t, "90", mr.HGet("opentd:balance:hold:"+res.RequestId, "amount"))
+
+	// the amend lands at a smaller amount and the old price
+	update(apis.OrderUpdateKind_UPDATE_AMENDED, &apis.Order{RequestId: res.RequestId, Price: 30, Amount: 2, RemainingAmount: 2, Revision: 3})
+	require.Equal(t, "40", balance("cash"))
+	require.Equal(t, "60", mr.HGet("opentd:balance:hold:"+res.RequestId, "amount"))
+
+	update(apis.OrderUpdateKind_UPDATE_CANCELLED, &apis.Order{RequestId: res.RequestId, Price: 30, Amount: 2, CancelledAmount: 2, Revision: 4})
+	require.Equal(t, "100", balance("cash"))
+	require.False(t, mr.Exists("opentd:balance:hold:"+res.RequestId))
+
+	update(apis.OrderUpdateKind_UPDATE_REJECTED, &apis.Order{RequestId: market.RequestId, Status: apis.OrderLifecycle_ORDER_REJECTED, Amount: 1, RemainingAmount: 1, Revision: 1})
+	require.Equal(t, "110", balance("cash"))
+	require.Equal(t, "0", balance("cash:held"))
+}
+
+func TestRiskAmendNotSent(t *testing.T) {
+	mr := miniredis.RunT(t)
+	conf := frontend.FrontConfig{
+		EventConfig: events.EventConfig{
+			EventType:      events.INMEMORY,
+			InMemoryConfig: events.InMemoryConfig{Topic: "risk-amend-orders"},
+		},
+		OrderUpdateConfig: events.EventConfig{
+			EventType:      events.INMEMORY,
+			InMemoryConfig: events.InMemoryConfig{Topic: "risk-amend-order-updates"},
+		},
+		RiskConfig:       frontend.RiskConfig{Enabled: true},
+		RedisConfig:      redis.Options{Addr: mr.Addr()},
+		LockExpireSecond: time.Minute,
+	}
+	f, err := frontend.NewFrontend(conf)
+	require.NoError(t, err)
+	addr := servetest.Serve(t, f)
+
+	ctx := context.Background()
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	c := apis.NewFrontendClient(conn)
+
+	mr.HSet("opentd:balance:user1", "cash", "100")
+	res, err := c.Buy(ctx, &apis.BuyRequest{UserId: "user1", Target: "t", Amount: 3, Price: 30})
+	require.NoError(t, err)
+	hold := "opentd:balance:hold:" + res.RequestId
+	amend := &apis.UpdateRequest{UserId: "user1", RequestId: res.RequestId, Target: "t", Amount: 3, Price: 33}
+	_, err = c.UpdateBuy(ctx, amend)
+	require.NoError(t, err)
+	require.Equal(t, "1", mr.HGet("opentd:balance:user1", "cash"))
+
+	// an amend refused by the lock leaves the hold alone
+	mr.HSet("opentd:balance:user1", "cash", "100")
+	amend.Amount = 4
+	_, err = c.UpdateBuy(ctx, amend)
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+	require.Equal(t, "100", mr.HGet("opentd:balance:user1", "cash"))
+	require.Equal(t, "99", mr.HGet(hold, "amount"))
+
+	// an amend that cannot be sent gives its top up back; a consumer that
+	// does not read blocks the send until the request ends
+	mr.Del(res.RequestId)
+	blocked, err := events.NewConsumerEvent(events.EventConfig{
+		EventType:      events.INMEMORY,
+		InMemoryConfig: events.InMemoryConfig{Topic: "risk-amend-orders", BufferSize: 1},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		// a receiver that ends at once unsubscribes the consumer
+		done, cancel := context.WithCancel(ctx)
+		cancel()
+		_ = blocked.StartReceiver(done, func(cloudevents.Event) {})
+	})
+	producer, err := events.NewProducerEvent(conf.EventConfig)
+	require.NoError(t, err)
+	e := cloudevents.NewEvent()
+	e.SetID("filler")
+	e.SetType(events.CancelType)
+	e.SetSource(events.FrontendSource)
+	require.False(t, cloudevents.IsUndelivered(producer.Send(ctx, e)))
+
+	sendCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	_, err = c.UpdateBuy(sendCtx, amend)
+	require.Error(t, err)
+	require.Eventually(t, func() bool {
+		return mr.HGet("opentd:balance:user1", "cash") == "100"
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, "99", mr.HGet(hold, "amount"))
+	require.Equal(t, "33", mr.HGet(hold, "unit"))
+	require.Equal(t, "3", mr.HGet(hold, "ordered"))
+	require.Equal(t, "99", mr.HGet("opentd:balance:user1", "cash:held"))
+}
@@ -0,0 +1,137 @@
+package frontend
+
+import (
+	"context"
+	"sync"
+
+	"github.com/atgane/opentd/apis"
+	"github.com/atgane/opentd/pkgs/events"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// updateHub fans order updates out to StreamOrderUpdates subscribers of the
+// order's user. A subscriber that cannot keep up has its channel closed
+// instead of silently missing updates.
+type updateHub struct {
+	mu         sync.Mutex
+	next       uint64
+	bufferSize int
+	subs       map[uint64]*updateSubscriber
+}
+
+type updateSubscriber struct {
+	userID string
+	ch     chan *apis.OrderUpdate
+}
+
+func newUpdateHub(bufferSize int) *updateHub {
+	if bufferSize <= 0 {
+		bufferSize = 256
+	}
+
+	h := new(updateHub)
+	h.bufferSize = bufferSize
+	h.subs = make(map[uint64]*updateSubscriber)
+	return h
+}
+
+func (h *updateHub) subscribe(userID string) (uint64, <-chan *apis.OrderUpdate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.next++
+	s := &updateSubscriber{
+		userID: userID,
+		ch:     make(chan *apis.OrderUpdate, h.bufferSize),
+	}
+	h.subs[h.next] = s
+	return h.next, s.ch
+}
+
+func (h *updateHub) unsubscribe(id uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if s, ok := h.subs[id]; ok {
+		delete(h.subs, id)
+		close(s.ch)
+	}
+}
+
+func (h *updateHub) broadcast(u *apis.OrderUpdate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for id, s := range h.subs {
+		if s.userID != u.Order.GetUserId() {
+			continue
+		}
+
+		select {
+		case s.ch <- u:
+		default:
+			delete(h.subs, id)
+			close(s.ch)
+		}
+	}
+}
+
+func (f *Frontend) StreamOrderUpdates(req *apis.StreamOrderUpdatesRequest, stream apis.Frontend_StreamOrderUpdatesServer) error {
+	log.Debug().Interface("req", req).Msg("order update stream accepted")
+
+	if req.UserId == "" {
+		return status.Errorf(codes.InvalidArgument, "user_id is required")
+	}
+
+	id, ch := f.updates.subscribe(req.UserId)
+	defer f.updates.unsubscribe(id)
+
+	// headers tell the client that no update is missed from here on
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-f.done:
+			return nil
+		case u, ok := <-ch:
+			if !ok {
+				msg := "order update stream fell behind"
+				log.Error().Str("user_id", req.UserId).Msg(msg)
+				return status.Errorf(codes.ResourceExhausted, msg)
+			}
+			if err := stream.Send(u); err != nil {
+				log.Error().
+					Err(err).
+					Str("user_id", req.UserId).
+					Str("request_id", u.Order.RequestId).
+					Msg("failed to stream.Send()")
+				return err
+			}
+		}
+	}
+}
+
+// receiveUpdate hands an order update event published by the dealer to the
+// subscribers of its user, after the hold of the order is brought in line
+// with it.
+func (f *Frontend) receiveUpdate(ctx context.Context, e cloudevents.Event) {
+	if e.Type() != events.OrderUpdateType {
+		return
+	}
+
+	u := new(apis.OrderUpdate)
+	if err := events.DataAs(e, u); err != nil {
+		log.Error().Err(err).Str("event_id", e.ID()).Msg("failed to events.DataAs()")
+		return
+	}
+	f.reconcile(ctx, u)
+	f.updates.broadcast(u)
+}
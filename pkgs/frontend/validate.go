@@ -0,0 +1,67 @@
+package frontend
+
+import (
+	"time"
+
+	"github.com/atgane/opentd/apis"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// orderRequest is implemented by apis.BuyRequest and apis.SellRequest.
+type orderRequest interface {
+	GetUserId() string
+	GetTarget() string
+	GetPrice() int64
+	GetAmount() int64
+	GetProtectionPrice() int64
+	GetOrderType() apis.OrderType
+	GetTimeInForce() apis.TimeInForce
+	GetExpireAt() int64
+	GetStopPrice() int64
+	GetDisplayAmount() int64
+	GetPostOnly() bool
+	GetSelfTradePrevention() apis.SelfTradePrevention
+}
+
+// validateOrder rejects order option combinations the matching engine would
+// reject anyway, so the caller gets an error instead of a silent drop.
+func validateOrder(req orderRequest) error {
+	orderType, tif := req.GetOrderType(), req.GetTimeInForce()
+
+	if _, ok := apis.OrderType_name[int32(orderType)]; !ok {
+		return status.Errorf(codes.InvalidArgument, "unknown order_type %d", orderType)
+	}
+	stop := orderType == apis.OrderType_STOP || orderType == apis.OrderType_STOP_LIMIT
+	if stop != (req.GetStopPrice() > 0) {
+		return status.Errorf(codes.InvalidArgument, "stop_price must be set for stop orders only")
+	}
+
+	if _, ok := apis.TimeInForce_name[int32(tif)]; !ok {
+		return status.Errorf(codes.InvalidArgument, "unknown time_in_force %d", tif)
+	}
+
+	if tif == apis.TimeInForce_GTD {
+		if req.GetExpireAt() <= time.Now().UnixMilli() {
+			return status.Errorf(codes.InvalidArgument, "expire_at must be in the future for GTD orders")
+		}
+	} else if req.GetExpireAt() != 0 {
+		return status.Errorf(codes.InvalidArgument, "expire_at is only allowed for GTD orders")
+	}
+
+	market := orderType == apis.OrderType_MARKET || orderType == apis.OrderType_STOP
+	if req.GetDisplayAmount() < 0 || (market && req.GetDisplayAmount() > 0) {
+		return status.Errorf(codes.InvalidArgument, "display_amount must not be negative and is only allowed for limit orders")
+	}
+	if orderType == apis.OrderType_MARKET && (tif == apis.TimeInForce_GTD || tif == apis.TimeInForce_DAY) {
+		return status.Errorf(codes.InvalidArgument, "market orders cannot rest, use GTC, IOC or FOK")
+	}
+
+	if req.GetPostOnly() && (orderType != apis.OrderType_LIMIT || tif == apis.TimeInForce_IOC || tif == apis.TimeInForce_FOK) {
+		return status.Errorf(codes.InvalidArgument, "post_only is only allowed for resting limit orders")
+	}
+	if _, ok := apis.SelfTradePrevention_name[int32(req.GetSelfTradePrevention())]; !ok {
+		return status.Errorf(codes.InvalidArgument, "unknown self_trade_prevention %d", req.GetSelfTradePrevention())
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// fileJournal stores one JSON encoded event per line and syncs every append.
+type fileJournal struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+	seq  uint64
+}
+
+func newFileJournal(conf JournalConfig) (*fileJournal, error) {
+	if conf.FilePath == "" {
+		return nil, fmt.Errorf("journal file path is required")
+	}
+
+	j := new(fileJournal)
+	j.path = conf.FilePath
+	if err := dropTornTail(j.path); err != nil {
+		return nil, err
+	}
+	if err := j.Read(context.Background(), 0, func(seq uint64, _ cloudevents.Event) error {
+		j.seq = seq
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(j.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	j.f = f
+	return j, nil
+}
+
+func (j *fileJournal) Append(ctx context.Context, e cloudevents.Event) (uint64, error) {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return 0, err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if _, err := j.f.Write(append(b, '\n')); err != nil {
+		return 0, err
+	}
+	if err := j.f.Sync(); err != nil {
+		return 0, err
+	}
+
+	j.seq++
+	return j.seq, nil
+}
+
+func (j *fileJournal) Read(ctx context.Context, after uint64, fn func(seq uint64, e cloudevents.Event) error) error {
+	f, err := os.Open(j.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var seq uint64
+	for scanner.Scan() {
+		seq++
+		if seq <= after {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		e := cloudevents.NewEvent()
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("corrupted journal entry %d: %w", seq, err)
+		}
+		if err := fn(seq, e); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Trim keeps the file whole, it is one append-only file and rewriting it on
+// every snapshot would cost more than the disk it frees.
+func (j *fileJournal) Trim(ctx context.Context, through uint64) error {
+	return nil
+}
+
+func (j *fileJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.f.Close()
+}
+
+// dropTornTail truncates a final record that misses its newline. Appends write
+// a whole line at once, so such a record is what a crash in the middle of an
+// append leaves behind and it was never acknowledged.
+func dropTornTail(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 64*1024)
+	end := info.Size()
+	for off := end; off > 0; {
+		n := int64(len(buf))
+		if off < n {
+			n = off
+		}
+		off -= n
+		if _, err := f.ReadAt(buf[:n], off); err != nil {
+			return err
+		}
+
+		if idx := bytes.LastIndexByte(buf[:n], '\n'); idx >= 0 {
+			return truncate(f, off+int64(idx)+1, end)
+		}
+	}
+	return truncate(f, 0, end)
+}
+
+func truncate(f *os.File, size, end int64) error {
+	if size == end {
+		return nil
+	}
+
+	log.Warn().
+		Str("path", f.Name()).
+		Int64("bytes", end-size).
+		Msg("dropping torn journal tail")
+	if err := f.Truncate(size); err != nil {
+		return err
+	}
+	return f.Sync()
+}
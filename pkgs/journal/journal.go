@@ -0,0 +1,58 @@
+package journal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/atgane/opentd/pkgs/engine"
+	"github.com/atgane/opentd/pkgs/events"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	REDIS = "redis"
+	FILE  = "file"
+)
+
+type JournalConfig struct {
+	JournalType string
+	RedisKey    string
+	FilePath    string
+}
+
+// Journal is an append-only log of order events in consumption order. The
+// sequence of an entry is its 1-based position in the log. Trim drops the
+// entries up to a sequence a saved snapshot already covers, the sequences of
+// the entries after it do not change.
+type Journal interface {
+	Append(ctx context.Context, e cloudevents.Event) (uint64, error)
+	Read(ctx context.Context, after uint64, fn func(seq uint64, e cloudevents.Event) error) error
+	Trim(ctx context.Context, through uint64) error
+	Close() error
+}
+
+func NewJournal(conf JournalConfig, redisClient *redis.Client) (Journal, error) {
+	switch conf.JournalType {
+	case REDIS:
+		return newRedisJournal(conf, redisClient)
+	case FILE:
+		return newFileJournal(conf)
+	}
+
+	return nil, fmt.Errorf("undefined journal")
+}
+
+// Replay applies every entry after the given sequence to en and returns the
+// last applied sequence. Engine errors are part of the recorded history and do
+// not stop the replay.
+func Replay(ctx context.Context, j Journal, en engine.Engine, after uint64) (uint64, error) {
+	last := after
+	err := j.Read(ctx, after, func(seq uint64, e cloudevents.Event) error {
+		events.SetSequence(&e, seq)
+		_ = engine.Apply(en, e)
+		last = seq
+		return nil
+	})
+	return last, err
+}
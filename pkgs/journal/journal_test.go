@@ -0,0 +1,194 @@
+package journal_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/atgane/opentd/apis"
+	"github.com/atgane/opentd/pkgs/engine"
+	"github.com/atgane/opentd/pkgs/events"
+	"github.com/atgane/opentd/pkgs/journal"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func event(t *testing.T, id string) cloudevents.Event {
+	t.Helper()
+
+	e := cloudevents.NewEvent()
+	e.SetID(id)
+	e.SetType(events.SellType)
+	e.SetSource(events.FrontendSource)
+	require.NoError(t, e.SetData(cloudevents.ApplicationJSON, &apis.SellRequest{UserId: "user", Target: "t", Amount: 1, Price: 10}))
+	return e
+}
+
+func ids(t *testing.T, j journal.Journal, after uint64) []string {
+	t.Helper()
+
+	var res []string
+	require.NoError(t, j.Read(context.Background(), after, func(seq uint64, e cloudevents.Event) error {
+		res = append(res, fmt.Sprintf("%d:%s", seq, e.ID()))
+		return nil
+	}))
+	return res
+}
+
+func TestFileJournalReplay(t *testing.T) {
+	ctx := context.Background()
+	conf := journal.JournalConfig{
+		JournalType: journal.FILE,
+		FilePath:    filepath.Join(t.TempDir(), "journal"),
+	}
+
+	j, err := journal.NewJournal(conf, nil)
+	require.NoError(t, err)
+
+	for idx := 0; idx < 10; idx++ {
+		typ, data := events.SellType, interface{}(&apis.SellRequest{UserId: fmt.Sprintf("user%d", idx), Target: "t", Amount: 2, Price: int64(10 + idx%3)})
+		if idx%2 == 1 {
+			typ, data = events.BuyType, &apis.BuyRequest{UserId: fmt.Sprintf("user%d", idx), Target: "t", Amount: 3, Price: 11}
+		}
+
+		e := cloudevents.NewEvent()
+		e.SetID(fmt.Sprintf("e%d", idx))
+		e.SetType(typ)
+		e.SetSource(events.FrontendSource)
+		require.NoError(t, e.SetData(cloudevents.ApplicationJSON, data))
+
+		seq, err := j.Append(ctx, e)
+		require.NoError(t, err)
+		require.Equal(t, uint64(idx+1), seq)
+	}
+	require.NoError(t, j.Close())
+
+	// reopening continues the sequence
+	j, err = journal.NewJournal(conf, nil)
+	require.NoError(t, err)
+	defer j.Close()
+
+	replayDeals := func() ([]byte, *engine.Snapshot) {
+		m, err := engine.NewMatchingEngine(engine.EngineConfig{HandleKey: "key"})
+		require.NoError(t, err)
+
+		last, err := journal.Replay(ctx, j, m, 0)
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), last)
+
+		b, err := json.Marshal(m.TakeDeals())
+		require.NoError(t, err)
+		return b, m.Snapshot()
+	}
+
+	first, snap := replayDeals()
+	second, _ := replayDeals()
+	require.Equal(t, first, second)
+	require.NotEqual(t, "null", string(first))
+	require.Equal(t, uint64(10), snap.JournalSeq)
+	require.Equal(t, "e9", snap.LastEventID)
+
+	// replaying the tail on top of a mid-way snapshot reaches the same state
+	m, err := engine.NewMatchingEngine(engine.EngineConfig{HandleKey: "key"})
+	require.NoError(t, err)
+	var mid *engine.Snapshot
+	require.NoError(t, j.Read(ctx, 0, func(seq uint64, e cloudevents.Event) error {
+		events.SetSequence(&e, seq)
+		_ = engine.Apply(m, e)
+		if seq == 4 {
+			mid = m.Snapshot()
+		}
+		return nil
+	}))
+
+	m, err = engine.NewMatchingEngine(engine.EngineConfig{HandleKey: "key"})
+	require.NoError(t, err)
+	require.NoError(t, m.Restore(mid))
+	_, err = journal.Replay(ctx, j, m, mid.JournalSeq)
+	require.NoError(t, err)
+
+	final := m.Snapshot()
+	final.PendingDeals, snap.PendingDeals = nil, nil
+	require.Equal(t, snap, final)
+}
+
+func TestFileJournalTornTail(t *testing.T) {
+	ctx := context.Background()
+	conf := journal.JournalConfig{
+		JournalType: journal.FILE,
+		FilePath:    filepath.Join(t.TempDir(), "journal"),
+	}
+
+	j, err := journal.NewJournal(conf, nil)
+	require.NoError(t, err)
+	for _, id := range []string{"e1", "e2"} {
+		_, err := j.Append(ctx, event(t, id))
+		require.NoError(t, err)
+	}
+	require.NoError(t, j.Close())
+
+	// a crash in the middle of an append leaves a line without its newline
+	f, err := os.OpenFile(conf.FilePath, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"specversion":"1.0","id":"e3`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	j, err = journal.NewJournal(conf, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"1:e1", "2:e2"}, ids(t, j, 0))
+
+	seq, err := j.Append(ctx, event(t, "e3"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), seq)
+	require.NoError(t, j.Close())
+
+	j, err = journal.NewJournal(conf, nil)
+	require.NoError(t, err)
+	defer j.Close()
+	require.Equal(t, []string{"1:e1", "2:e2", "3:e3"}, ids(t, j, 0))
+
+	// a complete entry that does not decode is still an error
+	require.NoError(t, os.WriteFile(conf.FilePath, []byte("{}\n"), 0644))
+	_, err = journal.NewJournal(conf, nil)
+	require.Error(t, err)
+}
+
+func TestRedisJournalTrim(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	j, err := journal.NewJournal(journal.JournalConfig{JournalType: journal.REDIS}, redisClient)
+	require.NoError(t, err)
+	for idx := 1; idx <= 5; idx++ {
+		seq, err := j.Append(ctx, event(t, fmt.Sprintf("e%d", idx)))
+		require.NoError(t, err)
+		require.Equal(t, uint64(idx), seq)
+	}
+
+	require.NoError(t, j.Trim(ctx, 3))
+	require.Equal(t, []string{"4:e4", "5:e5"}, ids(t, j, 3))
+	require.Equal(t, []string{"5:e5"}, ids(t, j, 4))
+	require.Error(t, j.Read(ctx, 2, func(uint64, cloudevents.Event) error { return nil }))
+
+	// sequences keep counting after a trim and an older trim is a no-op
+	seq, err := j.Append(ctx, event(t, "e6"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), seq)
+	require.NoError(t, j.Trim(ctx, 2))
+	require.Equal(t, []string{"4:e4", "5:e5", "6:e6"}, ids(t, j, 3))
+
+	// trimming everything keeps the sequence
+	require.NoError(t, j.Trim(ctx, 10))
+	require.Empty(t, ids(t, j, 6))
+	seq, err = j.Append(ctx, event(t, "e7"))
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), seq)
+	require.Equal(t, []string{"7:e7"}, ids(t, j, 6))
+}
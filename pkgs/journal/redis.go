@@ -0,0 +1,123 @@
+package journal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultRedisKey = "opentd:dealer:journal"
+	readBatchSize   = 1000
+)
+
+// appendScript returns the sequence of the pushed entry, the list length
+// plus the number of entries trimmed from its head.
+var appendScript = redis.NewScript(`
+local n = redis.call('RPUSH', KEYS[1], ARGV[1])
+return n + tonumber(redis.call('GET', KEYS[2]) or '0')
+`)
+
+// readScript returns the trimmed count followed by up to ARGV[2] entries
+// after the sequence ARGV[1], or only the trimmed count when some of those
+// entries are already trimmed.
+var readScript = redis.NewScript(`
+local trimmed = tonumber(redis.call('GET', KEYS[2]) or '0')
+local start = tonumber(ARGV[1]) - trimmed
+if start < 0 then
+	return {trimmed}
+end
+local entries = redis.call('LRANGE', KEYS[1], start, start + tonumber(ARGV[2]) - 1)
+table.insert(entries, 1, trimmed)
+return entries
+`)
+
+var trimScript = redis.NewScript(`
+local trimmed = tonumber(redis.call('GET', KEYS[2]) or '0')
+local drop = math.min(tonumber(ARGV[1]) - trimmed, redis.call('LLEN', KEYS[1]))
+if drop <= 0 then
+	return 0
+end
+redis.call('LTRIM', KEYS[1], drop, -1)
+redis.call('INCRBY', KEYS[2], drop)
+return drop
+`)
+
+// redisJournal keeps the log in a redis list. The number of entries trimmed
+// from the head of the list is kept next to it, so the sequence of an entry
+// is its list position plus that number.
+type redisJournal struct {
+	redisClient *redis.Client
+	key         string
+	trimmedKey  string
+}
+
+func newRedisJournal(conf JournalConfig, redisClient *redis.Client) (*redisJournal, error) {
+	if redisClient == nil {
+		return nil, fmt.Errorf("redis client is required")
+	}
+
+	j := new(redisJournal)
+	j.redisClient = redisClient
+	j.key = conf.RedisKey
+	if j.key == "" {
+		j.key = defaultRedisKey
+	}
+	j.trimmedKey = j.key + ":trimmed"
+	return j, nil
+}
+
+func (j *redisJournal) Append(ctx context.Context, e cloudevents.Event) (uint64, error) {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := appendScript.Run(ctx, j.redisClient, []string{j.key, j.trimmedKey}, b).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(n), nil
+}
+
+func (j *redisJournal) Read(ctx context.Context, after uint64, fn func(seq uint64, e cloudevents.Event) error) error {
+	for {
+		res, err := readScript.Run(ctx, j.redisClient, []string{j.key, j.trimmedKey}, after, readBatchSize).Slice()
+		if err != nil {
+			return err
+		}
+
+		trimmed, _ := res[0].(int64)
+		if after < uint64(trimmed) {
+			return fmt.Errorf("journal entries up to %d are trimmed, cannot read after %d", trimmed, after)
+		}
+
+		entries := res[1:]
+		for _, entry := range entries {
+			after++
+			s, _ := entry.(string)
+			e := cloudevents.NewEvent()
+			if err := json.Unmarshal([]byte(s), &e); err != nil {
+				return fmt.Errorf("corrupted journal entry %d: %w", after, err)
+			}
+			if err := fn(after, e); err != nil {
+				return err
+			}
+		}
+
+		if len(entries) < readBatchSize {
+			return nil
+		}
+	}
+}
+
+func (j *redisJournal) Trim(ctx context.Context, through uint64) error {
+	return trimScript.Run(ctx, j.redisClient, []string{j.key, j.trimmedKey}, through).Err()
+}
+
+func (j *redisJournal) Close() error {
+	return nil
+}
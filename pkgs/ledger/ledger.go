@@ -0,0 +1,535 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/atgane/opentd/apis"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	defaultKeyPrefix = "opentd:balance"
+	defaultCashAsset = "cash"
+	defaultFeeUserID = "opentd:fees"
+	defaultPageSize  = 50
+	maxPageSize      = 500
+	maxRetries       = 3
+)
+
+var (
+	ErrInvalidPageToken = errors.New("invalid page token")
+	// ErrOverdrawn is returned with a deal that was settled although a side
+	// could not pay for it. The engine matched the deal already, so it is
+	// posted anyway; the balances of the side go negative and the user is
+	// added to the "<prefix>:overdrawn" set until someone looks into it.
+	ErrOverdrawn = errors.New("balance overdrawn")
+
+	errHoldChanged = errors.New("hold changed")
+)
+
+// LedgerConfig must name the same balances as the frontend RiskConfig, since
+// settlement spends the holds the frontend makes.
+type LedgerConfig struct {
+	KeyPrefix string
+	CashAsset string
+	// FeeUserID is the account deal fees are paid to. It must not be the id
+	// of a user.
+	FeeUserID string
+}
+
+// Ledger settles deals against the balance hashes of the frontend risk check
+// and keeps the postings of every user in a redis list, oldest first.
+type Ledger struct {
+	redisClient *redis.Client
+	prefix      string
+	cashAsset   string
+	feeUserID   string
+}
+
+// settleScript applies a deal once. The buyer pays from the hold of the buy
+// order, or from the available cash when there is none, and gets back what the
+// hold covered beyond the deal price and its fee; the seller delivers from the
+// hold of the sell order the same way and pays its fee out of the proceeds.
+// ARGV[11] and ARGV[12] are the buyer and the seller. The postings of the
+// buyer, then of the seller, counted by ARGV[9] and ARGV[10], and then of the
+// fee account end ARGV. The postings are made for the holds ARGV[5] and
+// ARGV[6] were read from, so the script returns -1 when a hold changed since.
+// A side that cannot pay is overdrawn and flagged, and the script returns 2.
+var settleScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+local cash, target = ARGV[1], ARGV[2]
+local amount, value = tonumber(ARGV[3]), tonumber(ARGV[4])
+local buyerFee, sellerFee = tonumber(ARGV[7]), tonumber(ARGV[8])
+local function held(hold)
+	local h = redis.call('HMGET', hold, 'unit', 'amount')
+	if not h[1] then
+		return 0
+	end
+	return math.min(tonumber(h[1]) * amount, tonumber(h[2]))
+end
+local buyHeld, sellHeld = tonumber(ARGV[5]), tonumber(ARGV[6])
+if held(KEYS[4]) ~= buyHeld or held(KEYS[5]) ~= sellHeld then
+	return -1
+end
+local function spend(balance, hold, asset, held, cost)
+	if held == 0 then
+		redis.call('HINCRBY', balance, asset, -cost)
+		return
+	end
+	redis.call('HINCRBY', balance, asset .. ':held', -held)
+	redis.call('HINCRBY', balance, asset, held - cost)
+	redis.call('HINCRBY', hold, 'filled', amount)
+	if redis.call('HINCRBY', hold, 'amount', -held) <= 0 then
+		redis.call('DEL', hold)
+	end
+end
+spend(KEYS[2], KEYS[4], cash, buyHeld, value + buyerFee)
+spend(KEYS[3], KEYS[5], target, sellHeld, amount)
+redis.call('HINCRBY', KEYS[2], target, amount)
+redis.call('HINCRBY', KEYS[3], cash, value - sellerFee)
+if buyerFee + sellerFee > 0 then
+	redis.call('HINCRBY', KEYS[8], cash, buyerFee + sellerFee)
+end
+local ok = 1
+local function overdrawn(balance, asset, user)
+	if tonumber(redis.call('HGET', balance, asset) or '0') < 0 then
+		redis.call('SADD', KEYS[10], user)
+		ok = 2
+	end
+end
+overdrawn(KEYS[2], cash, ARGV[11])
+overdrawn(KEYS[3], target, ARGV[12])
+overdrawn(KEYS[3], cash, ARGV[12])
+local buyer, seller = tonumber(ARGV[9]), tonumber(ARGV[10])
+for i = 13, #ARGV do
+	local ledger = KEYS[9]
+	if i < 13 + buyer then
+		ledger = KEYS[6]
+	elseif i < 13 + buyer + seller then
+		ledger = KEYS[7]
+	end
+	redis.call('RPUSH', ledger, ARGV[i])
+end
+redis.call('SET', KEYS[1], '1')
+return ok
+`)
+
+// releaseScript gives the holds of orders back for the units a deal cancelled
+// once. Every order takes three ARGV, its cancelled units, the release read
+// from its hold and its posting, and three KEYS after the deal, its balance,
+// hold and ledger. A hold counts the units it was told were cancelled in
+// "reported"; the frontend may have released up to its "cancelled" before, so
+// only what goes beyond that is released here. It returns -1 when a hold
+// changed since it was read.
+var releaseScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+local holds = {}
+for i = 0, #ARGV / 3 - 1 do
+	local h = redis.call('HMGET', KEYS[3 + i * 3], 'asset', 'amount', 'unit', 'cancelled', 'reported')
+	local release = 0
+	if h[1] then
+		local reported = tonumber(h[5] or '0') + tonumber(ARGV[1 + i * 3])
+		local cancelled = math.max(tonumber(h[4] or '0'), reported)
+		release = math.min(tonumber(h[3]) * (cancelled - tonumber(h[4] or '0')), tonumber(h[2]))
+		holds[i] = {h[1], tonumber(h[2]) - release, reported, cancelled}
+	end
+	if release ~= tonumber(ARGV[2 + i * 3]) then
+		return -1
+	end
+end
+for i = 0, #ARGV / 3 - 1 do
+	local h = holds[i]
+	if h then
+		local balance, hold, release = KEYS[2 + i * 3], KEYS[3 + i * 3], tonumber(ARGV[2 + i * 3])
+		if release > 0 then
+			redis.call('HINCRBY', balance, h[1] .. ':held', -release)
+			redis.call('HINCRBY', balance, h[1], release)
+			redis.call('RPUSH', KEYS[4 + i * 3], ARGV[3 + i * 3])
+		end
+		if h[2] <= 0 then
+			redis.call('DEL', hold)
+		else
+			redis.call('HSET', hold, 'amount', h[2], 'reported', h[3], 'cancelled', h[4])
+		end
+	end
+end
+redis.call('SET', KEYS[1], '1')
+return 1
+`)
+
+func NewLedger(conf LedgerConfig, redisClient *redis.Client) (*Ledger, error) {
+	if redisClient == nil {
+		return nil, fmt.Errorf("redis client is required")
+	}
+
+	l := new(Ledger)
+	l.redisClient = redisClient
+	l.prefix = conf.KeyPrefix
+	if l.prefix == "" {
+		l.prefix = defaultKeyPrefix
+	}
+	l.cashAsset = conf.CashAsset
+	if l.cashAsset == "" {
+		l.cashAsset = defaultCashAsset
+	}
+	l.feeUserID = conf.FeeUserID
+	if l.feeUserID == "" {
+		l.feeUserID = defaultFeeUserID
+	}
+	return l, nil
+}
+
+// Settle posts the deal d, dated at, and reports whether it was new. A deal
+// that was settled before changes nothing, so deal events can be redelivered.
+// A deal is never refused, see ErrOverdrawn.
+func (l *Ledger) Settle(ctx context.Context, d *apis.GetDealStream, at time.Time) (bool, error) {
+	for i := 0; i < maxRetries; i++ {
+		settled, err := l.settle(ctx, d, at)
+		if errors.Is(err, errHoldChanged) {
+			continue
+		}
+		return settled, err
+	}
+	return false, fmt.Errorf("deal %s kept conflicting with other writers", d.DealId)
+}
+
+func (l *Ledger) settle(ctx context.Context, d *apis.GetDealStream, at time.Time) (bool, error) {
+	if d.Amount <= 0 || d.Price <= 0 {
+		return false, fmt.Errorf("deal %s has no fill to settle", d.DealId)
+	}
+	if d.BuyerFee < 0 || d.SellerFee < 0 {
+		return false, fmt.Errorf("deal %s has negative fees", d.DealId)
+	}
+	if d.Amount > math.MaxInt64/d.Price || d.Price*d.Amount > math.MaxInt64-d.BuyerFee-d.SellerFee {
+		return false, fmt.Errorf("deal %s value overflows", d.DealId)
+	}
+	value := d.Price * d.Amount
+	cost := value + d.BuyerFee
+
+	buyHeld, err := l.held(ctx, d.BuyOrderId, d.Amount)
+	if err != nil {
+		return false, err
+	}
+	sellHeld, err := l.held(ctx, d.SellOrderId, d.Amount)
+	if err != nil {
+		return false, err
+	}
+
+	// every posting goes to the ledger of the users whose accounts it moves,
+	// once even when a user trades with itself
+	var buyer, seller, fees []any
+	var n int
+	post := func(asset string, amount int64, debit, credit string, users ...string) error {
+		n++
+		b, err := protojson.Marshal(&apis.LedgerEntry{
+			EntryId:       fmt.Sprintf("%s-%d", d.DealId, n),
+			DealId:        d.DealId,
+			Asset:         asset,
+			Amount:        amount,
+			DebitAccount:  debit,
+			CreditAccount: credit,
+			CreatedAt:     at.UnixMilli(),
+		})
+		if err != nil {
+			return err
+		}
+		seen := make(map[string]struct{}, len(users))
+		for _, user := range users {
+			if _, ok := seen[user]; ok {
+				continue
+			}
+			seen[user] = struct{}{}
+			switch user {
+			case d.BuyerId:
+				buyer = append(buyer, string(b))
+			case d.SellerId:
+				seller = append(seller, string(b))
+			default:
+				fees = append(fees, string(b))
+			}
+		}
+		return nil
+	}
+
+	// a hold too small for the deal and its fee is released first and the
+	// buyer pays from the available cash
+	buyerCash := account(d.BuyerId, l.cashAsset, buyHeld >= cost)
+	if buyHeld > 0 && buyHeld < cost {
+		if err := post(l.cashAsset, buyHeld, account(d.BuyerId, l.cashAsset, true), buyerCash, d.BuyerId); err != nil {
+			return false, err
+		}
+	}
+	sellerCash := account(d.SellerId, l.cashAsset, false)
+	feeCash := account(l.feeUserID, l.cashAsset, false)
+	if err := post(l.cashAsset, value, buyerCash, sellerCash, d.BuyerId, d.SellerId); err != nil {
+		return false, err
+	}
+	if d.BuyerFee > 0 {
+		if err := post(l.cashAsset, d.BuyerFee, buyerCash, feeCash, d.BuyerId, l.feeUserID); err != nil {
+			return false, err
+		}
+	}
+	if refund := buyHeld - cost; refund > 0 {
+		if err := post(l.cashAsset, refund, buyerCash, account(d.BuyerId, l.cashAsset, false), d.BuyerId); err != nil {
+			return false, err
+		}
+	}
+	if err := post(d.Target, d.Amount, account(d.SellerId, d.Target, sellHeld > 0), account(d.BuyerId, d.Target, false), d.BuyerId, d.SellerId); err != nil {
+		return false, err
+	}
+	if d.SellerFee > 0 {
+		if err := post(l.cashAsset, d.SellerFee, sellerCash, feeCash, d.SellerId, l.feeUserID); err != nil {
+			return false, err
+		}
+	}
+
+	keys := []string{
+		l.dealKey(d.DealId),
+		l.balanceKey(d.BuyerId),
+		l.balanceKey(d.SellerId),
+		l.holdKey(d.BuyOrderId),
+		l.holdKey(d.SellOrderId),
+		l.ledgerKey(d.BuyerId),
+		l.ledgerKey(d.SellerId),
+		l.balanceKey(l.feeUserID),
+		l.ledgerKey(l.feeUserID),
+		l.overdrawnKey(),
+	}
+	args := []any{l.cashAsset, d.Target, d.Amount, value, buyHeld, sellHeld, d.BuyerFee, d.SellerFee, len(buyer), len(seller), d.BuyerId, d.SellerId}
+	args = append(args, buyer...)
+	args = append(args, seller...)
+	args = append(args, fees...)
+	ok, err := settleScript.Run(ctx, l.redisClient, keys, args...).Int()
+	if err != nil {
+		return false, err
+	}
+	return result(d, ok)
+}
+
+// Release gives back the share of the holds of the orders a cancel report or
+// self trade record d cancelled, dated at, and reports whether d was new.
+// Like Settle, a deal released before changes nothing.
+func (l *Ledger) Release(ctx context.Context, d *apis.GetDealStream, at time.Time) (bool, error) {
+	for i := 0; i < maxRetries; i++ {
+		released, err := l.release(ctx, d, at)
+		if errors.Is(err, errHoldChanged) {
+			continue
+		}
+		return released, err
+	}
+	return false, fmt.Errorf("deal %s kept conflicting with other writers", d.DealId)
+}
+
+func (l *Ledger) release(ctx context.Context, d *apis.GetDealStream, at time.Time) (bool, error) {
+	type cancel struct {
+		orderID, userID string
+		amount          int64
+	}
+	var cancels []cancel
+	switch {
+	case d.SelfTrade != nil:
+		// self trade records name the taker as buyer and the maker as seller
+		cancels = append(cancels,
+			cancel{d.SelfTrade.TakerOrderId, d.BuyerId, d.SelfTrade.TakerCancelled},
+			cancel{d.SelfTrade.MakerOrderId, d.SellerId, d.SelfTrade.MakerCancelled})
+	case d.CancelledAmount > 0 && d.BuyOrderId != "":
+		cancels = append(cancels, cancel{d.BuyOrderId, d.BuyerId, d.CancelledAmount})
+	case d.CancelledAmount > 0 && d.SellOrderId != "":
+		cancels = append(cancels, cancel{d.SellOrderId, d.SellerId, d.CancelledAmount})
+	default:
+		return false, fmt.Errorf("deal %s cancels nothing", d.DealId)
+	}
+
+	keys := []string{l.dealKey(d.DealId)}
+	var args []any
+	for i, c := range cancels {
+		if c.amount < 0 {
+			return false, fmt.Errorf("deal %s has negative cancelled amounts", d.DealId)
+		}
+		asset, release, err := l.releasable(ctx, c.orderID, c.amount)
+		if err != nil {
+			return false, err
+		}
+		var entry string
+		if release > 0 {
+			b, err := protojson.Marshal(&apis.LedgerEntry{
+				EntryId:       fmt.Sprintf("%s-%d", d.DealId, i+1),
+				DealId:        d.DealId,
+				Asset:         asset,
+				Amount:        release,
+				DebitAccount:  account(c.userID, asset, true),
+				CreditAccount: account(c.userID, asset, false),
+				CreatedAt:     at.UnixMilli(),
+			})
+			if err != nil {
+				return false, err
+			}
+			entry = string(b)
+		}
+		keys = append(keys, l.balanceKey(c.userID), l.holdKey(c.orderID), l.ledgerKey(c.userID))
+		args = append(args, c.amount, release, entry)
+	}
+
+	ok, err := releaseScript.Run(ctx, l.redisClient, keys, args...).Int()
+	if err != nil {
+		return false, err
+	}
+	return result(d, ok)
+}
+
+func result(d *apis.GetDealStream, ok int) (bool, error) {
+	switch ok {
+	case -1:
+		return false, errHoldChanged
+	case 2:
+		return true, fmt.Errorf("deal %s: %w", d.DealId, ErrOverdrawn)
+	}
+	return ok == 1, nil
+}
+
+// held returns how much of the hold of the order amount units of it spend, or
+// 0 when the order has no hold.
+func (l *Ledger) held(ctx context.Context, orderID string, amount int64) (int64, error) {
+	if orderID == "" {
+		return 0, nil
+	}
+
+	values, err := l.redisClient.HMGet(ctx, l.holdKey(orderID), "unit", "amount").Result()
+	if err != nil {
+		return 0, err
+	}
+	unitValue, ok := values[0].(string)
+	if !ok {
+		return 0, nil
+	}
+	unit, err := strconv.ParseInt(unitValue, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	remainingValue, _ := values[1].(string)
+	remaining, err := strconv.ParseInt(remainingValue, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if unit > 0 && amount > math.MaxInt64/unit {
+		return remaining, nil
+	}
+	return min(unit*amount, remaining), nil
+}
+
+// releasable returns the asset of the hold of an order and how much of it
+// cancelling amount more units of the order gives back, in the way
+// releaseScript counts it.
+func (l *Ledger) releasable(ctx context.Context, orderID string, amount int64) (string, int64, error) {
+	if orderID == "" {
+		return "", 0, nil
+	}
+
+	values, err := l.redisClient.HMGet(ctx, l.holdKey(orderID), "asset", "amount", "unit", "cancelled", "reported").Result()
+	if err != nil {
+		return "", 0, err
+	}
+	asset, ok := values[0].(string)
+	if !ok {
+		return "", 0, nil
+	}
+	var fields [4]int64
+	for i, v := range values[1:] {
+		value, _ := v.(string)
+		if value == "" {
+			continue
+		}
+		if fields[i], err = strconv.ParseInt(value, 10, 64); err != nil {
+			return "", 0, err
+		}
+	}
+	remaining, unit, cancelled, reported := fields[0], fields[1], fields[2], fields[3]
+	units := max(cancelled, reported+amount) - cancelled
+	if unit > 0 && units > math.MaxInt64/unit {
+		return asset, remaining, nil
+	}
+	return asset, min(unit*units, remaining), nil
+}
+
+// List returns the postings of req.UserId newest first. The ledger only grows
+// at its end, so the page token is the position of the oldest posting of the
+// previous page.
+func (l *Ledger) List(ctx context.Context, req *apis.ListLedgerEntriesRequest) (*apis.ListLedgerEntriesResponse, error) {
+	size := int64(req.PageSize)
+	if size <= 0 {
+		size = defaultPageSize
+	}
+	size = min(size, maxPageSize)
+
+	key := l.ledgerKey(req.UserId)
+	end, err := l.redisClient.LLen(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if req.PageToken != "" {
+		token, err := strconv.ParseInt(req.PageToken, 10, 64)
+		if err != nil || token < 0 {
+			return nil, ErrInvalidPageToken
+		}
+		end = min(end, token)
+	}
+
+	res := new(apis.ListLedgerEntriesResponse)
+	if end == 0 {
+		return res, nil
+	}
+	start := max(end-size, 0)
+	values, err := l.redisClient.LRange(ctx, key, start, end-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(values) - 1; i >= 0; i-- {
+		p := new(apis.LedgerEntry)
+		if err := protojson.Unmarshal([]byte(values[i]), p); err != nil {
+			return nil, err
+		}
+		res.Entries = append(res.Entries, p)
+	}
+	if start > 0 {
+		res.NextPageToken = strconv.FormatInt(start, 10)
+	}
+	return res, nil
+}
+
+// account names the available or held balance of asset of a user.
+func account(userID, asset string, held bool) string {
+	if held {
+		return userID + ":" + asset + ":held"
+	}
+	return userID + ":" + asset
+}
+
+func (l *Ledger) balanceKey(userID string) string {
+	return l.prefix + ":" + userID
+}
+
+func (l *Ledger) holdKey(orderID string) string {
+	return l.prefix + ":hold:" + orderID
+}
+
+func (l *Ledger) dealKey(dealID string) string {
+	return l.prefix + ":deal:" + dealID
+}
+
+func (l *Ledger) ledgerKey(userID string) string {
+	return l.prefix + ":ledger:" + userID
+}
+
+func (l *Ledger) overdrawnKey() string {
+	return l.prefix + ":overdrawn"
+}
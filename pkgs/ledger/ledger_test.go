@@ -0,0 +1,246 @@
+package ledger_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/atgane/opentd/apis"
+	"github.com/atgane/opentd/pkgs/ledger"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func newLedger(t *testing.T) (*ledger.Ledger, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	l, err := ledger.NewLedger(ledger.LedgerConfig{}, redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	require.NoError(t, err)
+	return l, mr
+}
+
+func TestSettle(t *testing.T) {
+	l, mr := newLedger(t)
+	ctx := context.Background()
+
+	// the frontend held 3 at 12 for the buy and 2 units for the sell
+	mr.HSet("opentd:balance:buyer", "cash", "4", "cash:held", "36")
+	mr.HSet("opentd:balance:hold:b1", "user", "buyer", "asset", "cash", "amount", "36", "unit", "12")
+	mr.HSet("opentd:balance:seller", "t", "1", "t:held", "2")
+	mr.HSet("opentd:balance:hold:s1", "user", "seller", "asset", "t", "amount", "2", "unit", "1")
+
+	deal := &apis.GetDealStream{
+		DealId:      "t-1",
+		Target:      "t",
+		Amount:      2,
+		Price:       10,
+		BuyerId:     "buyer",
+		SellerId:    "seller",
+		BuyOrderId:  "b1",
+		SellOrderId: "s1",
+	}
+	at := time.UnixMilli(1000)
+	settled, err := l.Settle(ctx, deal, at)
+	require.NoError(t, err)
+	require.True(t, settled)
+
+	// the buyer spends 24 of its hold, 20 for the deal and 4 back for the
+	// better price, and keeps 12 held for the unfilled unit
+	require.Equal(t, "8", mr.HGet("opentd:balance:buyer", "cash"))
+	require.Equal(t, "12", mr.HGet("opentd:balance:buyer", "cash:held"))
+	require.Equal(t, "2", mr.HGet("opentd:balance:buyer", "t"))
+	require.Equal(t, "12", mr.HGet("opentd:balance:hold:b1", "amount"))
+	require.Equal(t, "2", mr.HGet("opentd:balance:hold:b1", "filled"))
+	require.Equal(t, "20", mr.HGet("opentd:balance:seller", "cash"))
+	require.Equal(t, "0", mr.HGet("opentd:balance:seller", "t:held"))
+	require.Equal(t, "1", mr.HGet("opentd:balance:seller", "t"))
+	require.False(t, mr.Exists("opentd:balance:hold:s1"))
+
+	// a redelivered deal is posted once
+	settled, err = l.Settle(ctx, deal, at)
+	require.NoError(t, err)
+	require.False(t, settled)
+	require.Equal(t, "8", mr.HGet("opentd:balance:buyer", "cash"))
+
+	res, err := l.List(ctx, &apis.ListLedgerEntriesRequest{UserId: "buyer"})
+	require.NoError(t, err)
+	require.Len(t, res.Entries, 3)
+	require.Equal(t, "t-1-3", res.Entries[0].EntryId)
+	require.Equal(t, "seller:t:held", res.Entries[0].DebitAccount)
+	require.Equal(t, "buyer:t", res.Entries[0].CreditAccount)
+	require.Equal(t, int64(4), res.Entries[1].Amount)
+	require.Equal(t, "buyer:cash", res.Entries[1].CreditAccount)
+	require.Equal(t, "buyer:cash:held", res.Entries[2].DebitAccount)
+	require.Equal(t, "seller:cash", res.Entries[2].CreditAccount)
+	require.Equal(t, int64(1000), res.Entries[2].CreatedAt)
+
+	// the refund only concerns the buyer
+	res, err = l.List(ctx, &apis.ListLedgerEntriesRequest{UserId: "seller"})
+	require.NoError(t, err)
+	require.Len(t, res.Entries, 2)
+
+	// without holds the available balances pay
+	mr.HSet("opentd:balance:buyer", "cash", "12")
+	deal = &apis.GetDealStream{DealId: "t-2", Target: "t", Amount: 1, Price: 10, BuyerId: "buyer", SellerId: "seller"}
+	settled, err = l.Settle(ctx, deal, at)
+	require.NoError(t, err)
+	require.True(t, settled)
+	require.Equal(t, "2", mr.HGet("opentd:balance:buyer", "cash"))
+	require.Equal(t, "0", mr.HGet("opentd:balance:seller", "t"))
+
+	// a matched deal is posted even when a side cannot pay, which is flagged
+	deal = &apis.GetDealStream{DealId: "t-4", Target: "t", Amount: 1, Price: 10, BuyerId: "buyer", SellerId: "seller"}
+	settled, err = l.Settle(ctx, deal, at)
+	require.ErrorIs(t, err, ledger.ErrOverdrawn)
+	require.True(t, settled)
+	require.Equal(t, "-8", mr.HGet("opentd:balance:buyer", "cash"))
+	require.Equal(t, "-1", mr.HGet("opentd:balance:seller", "t"))
+	members, err := mr.Members("opentd:balance:overdrawn")
+	require.NoError(t, err)
+	require.Equal(t, []string{"buyer", "seller"}, members)
+	settled, err = l.Settle(ctx, deal, at)
+	require.NoError(t, err)
+	require.False(t, settled)
+
+	_, err = l.Settle(ctx, &apis.GetDealStream{DealId: "t-3", Target: "t", CancelledAmount: 1}, at)
+	require.Error(t, err)
+}
+
+func TestSettleFees(t *testing.T) {
+	l, mr := newLedger(t)
+	ctx := context.Background()
+
+	mr.HSet("opentd:balance:buyer", "cash", "20", "cash:held", "2010")
+	mr.HSet("opentd:balance:hold:b1", "user", "buyer", "asset", "cash", "amount", "1010", "unit", "101")
+	mr.HSet("opentd:balance:hold:b2", "user", "buyer", "asset", "cash", "amount", "1000", "unit", "100")
+	mr.HSet("opentd:balance:seller", "t", "20")
+
+	deal := &apis.GetDealStream{DealId: "t-1", Target: "t", Amount: 10, Price: 100, BuyerId: "buyer", SellerId: "seller", BuyOrderId: "b1", BuyerFee: 10, SellerFee: 5}
+	_, err := l.Settle(ctx, deal, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, "20", mr.HGet("opentd:balance:buyer", "cash"))
+	require.Equal(t, "1000", mr.HGet("opentd:balance:buyer", "cash:held"))
+	require.False(t, mr.Exists("opentd:balance:hold:b1"))
+	require.Equal(t, "995", mr.HGet("opentd:balance:seller", "cash"))
+	require.Equal(t, "15", mr.HGet("opentd:balance:opentd:fees", "cash"))
+
+	res, err := l.List(ctx, &apis.ListLedgerEntriesRequest{UserId: "opentd:fees"})
+	require.NoError(t, err)
+	require.Len(t, res.Entries, 2)
+	require.Equal(t, "seller:cash", res.Entries[0].DebitAccount)
+	require.Equal(t, "buyer:cash:held", res.Entries[1].DebitAccount)
+
+	// a hold that does not cover the fee is released and the rest is paid
+	// from the available cash
+	deal = &apis.GetDealStream{DealId: "t-2", Target: "t", Amount: 10, Price: 100, BuyerId: "buyer", SellerId: "seller", BuyOrderId: "b2", BuyerFee: 10}
+	_, err = l.Settle(ctx, deal, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, "10", mr.HGet("opentd:balance:buyer", "cash"))
+	require.Equal(t, "0", mr.HGet("opentd:balance:buyer", "cash:held"))
+	require.Equal(t, "25", mr.HGet("opentd:balance:opentd:fees", "cash"))
+
+	res, err = l.List(ctx, &apis.ListLedgerEntriesRequest{UserId: "buyer", PageSize: 4})
+	require.NoError(t, err)
+	require.Equal(t, "buyer:cash:held", res.Entries[3].DebitAccount)
+	require.Equal(t, "buyer:cash", res.Entries[3].CreditAccount)
+	require.Equal(t, int64(1000), res.Entries[3].Amount)
+	require.Equal(t, "buyer:cash", res.Entries[2].DebitAccount)
+}
+
+func TestRelease(t *testing.T) {
+	l, mr := newLedger(t)
+	ctx := context.Background()
+	at := time.UnixMilli(1000)
+
+	mr.HSet("opentd:balance:user1", "cash:held", "90", "t:held", "3")
+	mr.HSet("opentd:balance:hold:b1", "user", "user1", "asset", "cash", "amount", "90", "unit", "30", "ordered", "3")
+	mr.HSet("opentd:balance:hold:s1", "user", "user1", "asset", "t", "amount", "3", "unit", "1", "ordered", "3")
+
+	// the rest of an ioc buy is cancelled
+	report := &apis.GetDealStream{DealId: "t-1", Target: "t", CancelledAmount: 1, BuyerId: "user1", BuyOrderId: "b1"}
+	released, err := l.Release(ctx, report, at)
+	require.NoError(t, err)
+	require.True(t, released)
+	require.Equal(t, "30", mr.HGet("opentd:balance:user1", "cash"))
+	require.Equal(t, "60", mr.HGet("opentd:balance:user1", "cash:held"))
+	require.Equal(t, "60", mr.HGet("opentd:balance:hold:b1", "amount"))
+
+	released, err = l.Release(ctx, report, at)
+	require.NoError(t, err)
+	require.False(t, released)
+	require.Equal(t, "30", mr.HGet("opentd:balance:user1", "cash"))
+
+	// the frontend already gave back the unit the self trade cancels of the
+	// buy, so only the sell is released
+	mr.HSet("opentd:balance:hold:b1", "cancelled", "2", "amount", "30")
+	mr.HSet("opentd:balance:user1", "cash", "60", "cash:held", "30")
+	record := &apis.GetDealStream{
+		DealId:      "t-2",
+		Target:      "t",
+		BuyerId:     "user1",
+		SellerId:    "user1",
+		BuyOrderId:  "b1",
+		SellOrderId: "s1",
+		SelfTrade:   &apis.SelfTrade{Mode: apis.SelfTradePrevention_CANCEL_BOTH, TakerOrderId: "b1", TakerCancelled: 1, MakerOrderId: "s1", MakerCancelled: 3},
+	}
+	released, err = l.Release(ctx, record, at)
+	require.NoError(t, err)
+	require.True(t, released)
+	require.Equal(t, "60", mr.HGet("opentd:balance:user1", "cash"))
+	require.Equal(t, "30", mr.HGet("opentd:balance:hold:b1", "amount"))
+	require.Equal(t, "3", mr.HGet("opentd:balance:user1", "t"))
+	require.Equal(t, "0", mr.HGet("opentd:balance:user1", "t:held"))
+	require.False(t, mr.Exists("opentd:balance:hold:s1"))
+
+	res, err := l.List(ctx, &apis.ListLedgerEntriesRequest{UserId: "user1"})
+	require.NoError(t, err)
+	require.Len(t, res.Entries, 2)
+	require.Equal(t, "t-2-2", res.Entries[0].EntryId)
+	require.Equal(t, "user1:t:held", res.Entries[0].DebitAccount)
+	require.Equal(t, "user1:cash", res.Entries[1].CreditAccount)
+	require.Equal(t, int64(30), res.Entries[1].Amount)
+
+	_, err = l.Release(ctx, &apis.GetDealStream{DealId: "t-3", Target: "t", Amount: 1, Price: 10}, at)
+	require.Error(t, err)
+}
+
+func TestList(t *testing.T) {
+	l, mr := newLedger(t)
+	ctx := context.Background()
+	mr.HSet("opentd:balance:u1", "cash", "3")
+	mr.HSet("opentd:balance:u2", "t", "3")
+	mr.HSet("opentd:balance:u3", "cash", "1", "t", "1")
+
+	for _, id := range []string{"t-1", "t-2", "t-3"} {
+		_, err := l.Settle(ctx, &apis.GetDealStream{DealId: id, Target: "t", Amount: 1, Price: 1, BuyerId: "u1", SellerId: "u2"}, time.Now())
+		require.NoError(t, err)
+	}
+
+	ids := func(res *apis.ListLedgerEntriesResponse) []string {
+		var ids []string
+		for _, p := range res.Entries {
+			ids = append(ids, p.EntryId)
+		}
+		return ids
+	}
+
+	res, err := l.List(ctx, &apis.ListLedgerEntriesRequest{UserId: "u2", PageSize: 4})
+	require.NoError(t, err)
+	require.Equal(t, []string{"t-3-2", "t-3-1", "t-2-2", "t-2-1"}, ids(res))
+	res, err = l.List(ctx, &apis.ListLedgerEntriesRequest{UserId: "u2", PageSize: 4, PageToken: res.NextPageToken})
+	require.NoError(t, err)
+	require.Equal(t, []string{"t-1-2", "t-1-1"}, ids(res))
+	require.Empty(t, res.NextPageToken)
+
+	// a user trading with itself sees every posting once
+	_, err = l.Settle(ctx, &apis.GetDealStream{DealId: "t-4", Target: "t", Amount: 1, Price: 1, BuyerId: "u3", SellerId: "u3"}, time.Now())
+	require.NoError(t, err)
+	res, err = l.List(ctx, &apis.ListLedgerEntriesRequest{UserId: "u3"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"t-4-2", "t-4-1"}, ids(res))
+
+	_, err = l.List(ctx, &apis.ListLedgerEntriesRequest{UserId: "u1", PageToken: "bad"})
+	require.ErrorIs(t, err, ledger.ErrInvalidPageToken)
+}
@@ -0,0 +1,268 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/atgane/opentd/apis"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	defaultKeyPrefix = "opentd:orders"
+	defaultPageSize  = 50
+	maxPageSize      = 500
+)
+
+var (
+	ErrNotFound         = errors.New("order not found")
+	ErrInvalidPageToken = errors.New("invalid page token")
+)
+
+type OrderStoreConfig struct {
+	KeyPrefix string
+	// TerminalTTL is how long filled, cancelled and rejected orders are kept.
+	// 0 keeps them forever.
+	TerminalTTL time.Duration
+}
+
+// Store keeps the latest state of every order in redis. Each order is one key
+// holding its protojson encoding, and every user has a sorted set of its order
+// ids, plus one per target, scored by the journal sequence of the order so
+// listing is newest first and stable while new orders arrive. The outcome of
+// every applied request is kept next to the orders for GetOrderStatus.
+type Store struct {
+	redisClient *redis.Client
+	prefix      string
+	terminalTTL time.Duration
+}
+
+func NewStore(conf OrderStoreConfig, redisClient *redis.Client) (*Store, error) {
+	if redisClient == nil {
+		return nil, fmt.Errorf("redis client is required")
+	}
+	if conf.TerminalTTL < 0 {
+		return nil, fmt.Errorf("terminal ttl must not be negative")
+	}
+
+	s := new(Store)
+	s.redisClient = redisClient
+	s.prefix = conf.KeyPrefix
+	if s.prefix == "" {
+		s.prefix = defaultKeyPrefix
+	}
+	s.terminalTTL = conf.TerminalTTL
+	return s, nil
+}
+
+// Save overwrites the state of o. The dealer calls it in engine order, so the
+// last write is always the latest state.
+func (s *Store) Save(ctx context.Context, o *apis.Order) error {
+	b, err := protojson.Marshal(o)
+	if err != nil {
+		return err
+	}
+
+	var ttl time.Duration
+	if terminal(o.Status) {
+		ttl = s.terminalTTL
+	}
+
+	pipe := s.redisClient.TxPipeline()
+	pipe.Set(ctx, s.orderKey(o.RequestId), b, ttl)
+	member := redis.Z{Score: float64(o.Seq), Member: o.RequestId}
+	pipe.ZAdd(ctx, s.userKey(o.UserId), member)
+	pipe.ZAdd(ctx, s.targetKey(o.UserId, o.Target), member)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// SavePending records an order the frontend accepted but the dealer has not
+// applied yet. It never overwrites a state the dealer already saved. Pending
+// orders have no journal sequence yet, so only Get finds them.
+func (s *Store) SavePending(ctx context.Context, o *apis.Order) error {
+	b, err := protojson.Marshal(o)
+	if err != nil {
+		return err
+	}
+
+	return s.redisClient.SetNX(ctx, s.orderKey(o.RequestId), b, 0).Err()
+}
+
+// DeletePending removes the record SavePending wrote for an order that was
+// never sent to the dealer. A state the dealer saved is kept.
+func (s *Store) DeletePending(ctx context.Context, requestID string) error {
+	key := s.orderKey(requestID)
+	return s.redisClient.Watch(ctx, func(tx *redis.Tx) error {
+		b, err := tx.Get(ctx, key).Bytes()
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		o := new(apis.Order)
+		if err := protojson.Unmarshal(b, o); err != nil {
+			return err
+		}
+		if o.Status != apis.OrderLifecycle_ORDER_PENDING {
+			return nil
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, key)
+			return nil
+		})
+		return err
+	}, key)
+}
+
+func (s *Store) Get(ctx context.Context, requestID string) (*apis.Order, error) {
+	b, err := s.redisClient.Get(ctx, s.orderKey(requestID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	o := new(apis.Order)
+	if err := protojson.Unmarshal(b, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// SaveStatus records the outcome of applying a request. Outcomes never change,
+// so they are kept as long as terminal orders.
+func (s *Store) SaveStatus(ctx context.Context, st *apis.OrderStatus) error {
+	b, err := protojson.Marshal(st)
+	if err != nil {
+		return err
+	}
+
+	return s.redisClient.Set(ctx, s.statusKey(st.RequestId), b, s.terminalTTL).Err()
+}
+
+func (s *Store) GetStatus(ctx context.Context, requestID string) (*apis.OrderStatus, error) {
+	b, err := s.redisClient.Get(ctx, s.statusKey(requestID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	st := new(apis.OrderStatus)
+	if err := protojson.Unmarshal(b, st); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// List returns the orders of req.UserId newest first. The page token is the
+// sequence of the last order of the previous page.
+func (s *Store) List(ctx context.Context, req *apis.ListOrdersRequest) (*apis.ListOrdersResponse, error) {
+	size := int(req.PageSize)
+	if size <= 0 {
+		size = defaultPageSize
+	}
+	size = min(size, maxPageSize)
+
+	cursor := "+inf"
+	if req.PageToken != "" {
+		if _, err := strconv.ParseUint(req.PageToken, 10, 64); err != nil {
+			return nil, ErrInvalidPageToken
+		}
+		cursor = "(" + req.PageToken
+	}
+
+	statuses := make(map[apis.OrderLifecycle]struct{}, len(req.Statuses))
+	for _, st := range req.Statuses {
+		statuses[st] = struct{}{}
+	}
+
+	key := s.userKey(req.UserId)
+	if req.Target != "" {
+		key = s.targetKey(req.UserId, req.Target)
+	}
+
+	res := new(apis.ListOrdersResponse)
+	for len(res.Orders) < size {
+		members, err := s.redisClient.ZRevRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+			Max:   cursor,
+			Min:   "-inf",
+			Count: int64(size),
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(members) == 0 {
+			return res, nil
+		}
+
+		keys := make([]string, len(members))
+		for i := range members {
+			keys[i] = s.orderKey(members[i].Member)
+		}
+		values, err := s.redisClient.MGet(ctx, keys...).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		for i, v := range values {
+			seq := strconv.FormatUint(uint64(members[i].Score), 10)
+			cursor = "(" + seq
+
+			raw, ok := v.(string)
+			if !ok {
+				// the order expired after its terminal ttl
+				s.redisClient.ZRem(ctx, key, members[i].Member)
+				continue
+			}
+			o := new(apis.Order)
+			if err := protojson.Unmarshal([]byte(raw), o); err != nil {
+				return nil, err
+			}
+			if _, ok := statuses[o.Status]; len(statuses) > 0 && !ok {
+				continue
+			}
+
+			res.Orders = append(res.Orders, o)
+			if len(res.Orders) == size {
+				res.NextPageToken = seq
+				return res, nil
+			}
+		}
+	}
+	return res, nil
+}
+
+func (s *Store) orderKey(requestID string) string {
+	return s.prefix + ":order:" + requestID
+}
+
+func (s *Store) statusKey(requestID string) string {
+	return s.prefix + ":status:" + requestID
+}
+
+func (s *Store) userKey(userID string) string {
+	return s.prefix + ":user:" + userID
+}
+
+func (s *Store) targetKey(userID, target string) string {
+	return s.prefix + ":user:" + userID + ":" + target
+}
+
+func terminal(st apis.OrderLifecycle) bool {
+	switch st {
+	case apis.OrderLifecycle_ORDER_FILLED, apis.OrderLifecycle_ORDER_CANCELLED, apis.OrderLifecycle_ORDER_REJECTED:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package orders_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/atgane/opentd/apis"
+	"github.com/atgane/opentd/pkgs/orders"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func newStore(t *testing.T) (*orders.Store, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	s, err := orders.NewStore(orders.OrderStoreConfig{TerminalTTL: time.Minute}, redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	require.NoError(t, err)
+	return s, mr
+}
+
+func TestGet(t *testing.T) {
+	s, _ := newStore(t)
+	ctx := context.Background()
+
+	_, err := s.Get(ctx, "r1")
+	require.ErrorIs(t, err, orders.ErrNotFound)
+
+	pending := &apis.Order{RequestId: "r1", UserId: "u1", Target: "t", Status: apis.OrderLifecycle_ORDER_PENDING}
+	require.NoError(t, s.SavePending(ctx, pending))
+	o, err := s.Get(ctx, "r1")
+	require.NoError(t, err)
+	require.Equal(t, apis.OrderLifecycle_ORDER_PENDING, o.Status)
+
+	// a late pending write never hides the state saved by the dealer
+	open := &apis.Order{RequestId: "r1", UserId: "u1", Target: "t", Status: apis.OrderLifecycle_ORDER_OPEN, Seq: 1}
+	require.NoError(t, s.Save(ctx, open))
+	require.NoError(t, s.SavePending(ctx, pending))
+	o, err = s.Get(ctx, "r1")
+	require.NoError(t, err)
+	require.Equal(t, apis.OrderLifecycle_ORDER_OPEN, o.Status)
+
+	// only pending records of orders that were never sent are deleted
+	require.NoError(t, s.DeletePending(ctx, "r1"))
+	_, err = s.Get(ctx, "r1")
+	require.NoError(t, err)
+	require.NoError(t, s.SavePending(ctx, &apis.Order{RequestId: "r2", UserId: "u1", Target: "t", Status: apis.OrderLifecycle_ORDER_PENDING}))
+	require.NoError(t, s.DeletePending(ctx, "r2"))
+	_, err = s.Get(ctx, "r2")
+	require.ErrorIs(t, err, orders.ErrNotFound)
+	require.NoError(t, s.DeletePending(ctx, "r3"))
+}
+
+func TestStatus(t *testing.T) {
+	s, mr := newStore(t)
+	ctx := context.Background()
+
+	_, err := s.GetStatus(ctx, "r1")
+	require.ErrorIs(t, err, orders.ErrNotFound)
+
+	st := &apis.OrderStatus{RequestId: "r1", UserId: "u1", Target: "t", State: apis.OrderState_REJECTED, Reason: apis.RejectReason_ORDER_NOT_FOUND}
+	require.NoError(t, s.SaveStatus(ctx, st))
+	got, err := s.GetStatus(ctx, "r1")
+	require.NoError(t, err)
+	require.Equal(t, st.String(), got.String())
+
+	// outcomes are kept as long as terminal orders
+	mr.FastForward(time.Minute)
+	_, err = s.GetStatus(ctx, "r1")
+	require.ErrorIs(t, err, orders.ErrNotFound)
+}
+
+func TestList(t *testing.T) {
+	s, mr := newStore(t)
+	ctx := context.Background()
+
+	for seq := uint64(1); seq <= 5; seq++ {
+		o := &apis.Order{RequestId: fmt.Sprintf("r%d", seq), UserId: "u1", Target: "t", Status: apis.OrderLifecycle_ORDER_OPEN, Seq: seq}
+		if seq%2 == 0 {
+			o.Target = "v"
+			o.Status = apis.OrderLifecycle_ORDER_FILLED
+		}
+		require.NoError(t, s.Save(ctx, o))
+	}
+	require.NoError(t, s.Save(ctx, &apis.Order{RequestId: "x1", UserId: "u2", Target: "t", Seq: 6}))
+
+	ids := func(res *apis.ListOrdersResponse) []string {
+		var ids []string
+		for _, o := range res.Orders {
+			ids = append(ids, o.RequestId)
+		}
+		return ids
+	}
+
+	// newest first, paged by sequence
+	res, err := s.List(ctx, &apis.ListOrdersRequest{UserId: "u1", PageSize: 2})
+	require.NoError(t, err)
+	require.Equal(t, []string{"r5", "r4"}, ids(res))
+	res, err = s.List(ctx, &apis.ListOrdersRequest{UserId: "u1", PageSize: 2, PageToken: res.NextPageToken})
+	require.NoError(t, err)
+	require.Equal(t, []string{"r3", "r2"}, ids(res))
+	res, err = s.List(ctx, &apis.ListOrdersRequest{UserId: "u1", PageSize: 2, PageToken: res.NextPageToken})
+	require.NoError(t, err)
+	require.Equal(t, []string{"r1"}, ids(res))
+	require.Empty(t, res.NextPageToken)
+
+	res, err = s.List(ctx, &apis.ListOrdersRequest{UserId: "u1", Target: "t"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"r5", "r3", "r1"}, ids(res))
+
+	res, err = s.List(ctx, &apis.ListOrdersRequest{UserId: "u1", Statuses: []apis.OrderLifecycle{apis.OrderLifecycle_ORDER_FILLED}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"r4", "r2"}, ids(res))
+
+	// terminal orders expire and drop out of the listing
+	mr.FastForward(2 * time.Minute)
+	res, err = s.List(ctx, &apis.ListOrdersRequest{UserId: "u1"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"r5", "r3", "r1"}, ids(res))
+
+	_, err = s.List(ctx, &apis.ListOrdersRequest{UserId: "u1", PageToken: "bad"})
+	require.ErrorIs(t, err, orders.ErrInvalidPageToken)
+}
@@ -0,0 +1,213 @@
+package positions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/atgane/opentd/apis"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	defaultKeyPrefix = "opentd:positions"
+	maxRetries       = 3
+)
+
+type PositionsConfig struct {
+	KeyPrefix string
+}
+
+// Tracker keeps the positions of every user in redis, one hash per user with
+// a field per target, and the last deal price of every target. Positions are
+// only stored with their amount, cost, realized pnl and fees; the rest is
+// derived when they are read.
+type Tracker struct {
+	redisClient *redis.Client
+	prefix      string
+}
+
+func NewTracker(conf PositionsConfig, redisClient *redis.Client) (*Tracker, error) {
+	if redisClient == nil {
+		return nil, fmt.Errorf("redis client is required")
+	}
+
+	t := new(Tracker)
+	t.redisClient = redisClient
+	t.prefix = conf.KeyPrefix
+	if t.prefix == "" {
+		t.prefix = defaultKeyPrefix
+	}
+	return t, nil
+}
+
+// Apply moves the positions of both sides of d and reports whether d was new,
+// like ledger.Settle, so deal events can be redelivered.
+func (t *Tracker) Apply(ctx context.Context, d *apis.GetDealStream) (bool, error) {
+	if d.Amount <= 0 || d.Price <= 0 {
+		return false, fmt.Errorf("deal %s has no fill to apply", d.DealId)
+	}
+
+	dealKey := t.dealKey(d.DealId)
+	buyerKey, sellerKey := t.userKey(d.BuyerId), t.userKey(d.SellerId)
+	applied := false
+	apply := func(tx *redis.Tx) error {
+		n, err := tx.Exists(ctx, dealKey).Result()
+		if err != nil || n > 0 {
+			return err
+		}
+
+		buyer, err := t.load(ctx, tx, d.BuyerId, d.Target)
+		if err != nil {
+			return err
+		}
+		seller := buyer
+		if d.SellerId != d.BuyerId {
+			if seller, err = t.load(ctx, tx, d.SellerId, d.Target); err != nil {
+				return err
+			}
+		}
+		trade(buyer, d.Amount, d.Price)
+		buyer.Fees += d.BuyerFee
+		trade(seller, -d.Amount, d.Price)
+		seller.Fees += d.SellerFee
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for key, p := range map[string]*apis.Position{buyerKey: buyer, sellerKey: seller} {
+				b, err := protojson.Marshal(p)
+				if err != nil {
+					return err
+				}
+				pipe.HSet(ctx, key, d.Target, b)
+			}
+			pipe.HSet(ctx, t.lastKey(), d.Target, d.Price)
+			pipe.Set(ctx, dealKey, 1, 0)
+			return nil
+		})
+		if err == nil {
+			applied = true
+		}
+		return err
+	}
+
+	for i := 0; i < maxRetries; i++ {
+		err := t.redisClient.Watch(ctx, apply, dealKey, buyerKey, sellerKey)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		return applied, err
+	}
+	return false, fmt.Errorf("deal %s kept conflicting with other writers", d.DealId)
+}
+
+func (t *Tracker) load(ctx context.Context, tx *redis.Tx, userID, target string) (*apis.Position, error) {
+	b, err := tx.HGet(ctx, t.userKey(userID), target).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return &apis.Position{Target: target}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p := new(apis.Position)
+	if err := protojson.Unmarshal(b, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Get returns the positions of a user valued at the last price of each
+// target.
+func (t *Tracker) Get(ctx context.Context, userID string) ([]*apis.Position, error) {
+	values, err := t.redisClient.HGetAll(ctx, t.userKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	targets := make([]string, 0, len(values))
+	for target := range values {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	prices, err := t.redisClient.HMGet(ctx, t.lastKey(), targets...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make([]*apis.Position, 0, len(targets))
+	for i, target := range targets {
+		p := new(apis.Position)
+		if err := protojson.Unmarshal([]byte(values[target]), p); err != nil {
+			return nil, err
+		}
+		if price, ok := prices[i].(string); ok {
+			if p.LastPrice, err = strconv.ParseInt(price, 10, 64); err != nil {
+				return nil, err
+			}
+		}
+		if p.Amount != 0 {
+			p.AveragePrice = float64(p.Cost) / float64(p.Amount)
+			p.UnrealizedPnl = p.Amount*p.LastPrice - p.Cost
+		}
+		positions = append(positions, p)
+	}
+	return positions, nil
+}
+
+// trade adds amount, bought when positive and sold when negative, at price to
+// p. The part that reduces the position realizes its pnl against the average
+// cost, and what is left over opens the other way at price.
+func trade(p *apis.Position, amount, price int64) {
+	if p.Amount == 0 || (p.Amount > 0) == (amount > 0) {
+		p.Amount += amount
+		p.Cost += amount * price
+		return
+	}
+
+	closed := -amount
+	if abs(closed) > abs(p.Amount) {
+		closed = p.Amount
+	}
+	basis := mulDiv(p.Cost, closed, p.Amount)
+	p.RealizedPnl += closed*price - basis
+	p.Amount -= closed
+	p.Cost -= basis
+
+	if rest := amount + closed; rest != 0 {
+		p.Amount = rest
+		p.Cost = rest * price
+	}
+}
+
+// mulDiv returns a*b/c without overflowing in between.
+func mulDiv(a, b, c int64) int64 {
+	r := new(big.Int).Mul(big.NewInt(a), big.NewInt(b))
+	return r.Quo(r, big.NewInt(c)).Int64()
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func (t *Tracker) userKey(userID string) string {
+	return t.prefix + ":user:" + userID
+}
+
+func (t *Tracker) lastKey() string {
+	return t.prefix + ":last"
+}
+
+func (t *Tracker) dealKey(dealID string) string {
+	return t.prefix + ":deal:" + dealID
+}
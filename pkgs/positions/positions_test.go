@@ -0,0 +1,88 @@
+package positions_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/atgane/opentd/apis"
+	"github.com/atgane/opentd/pkgs/positions"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPositions(t *testing.T) {
+	mr := miniredis.RunT(t)
+	tr, err := positions.NewTracker(positions.PositionsConfig{}, redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	var seq int
+	deal := func(buyer, seller string, amount, price int64) *apis.GetDealStream {
+		t.Helper()
+		seq++
+		d := &apis.GetDealStream{DealId: fmt.Sprintf("t-%d", seq), Target: "t", Amount: amount, Price: price, BuyerId: buyer, SellerId: seller, BuyerFee: 1}
+		applied, err := tr.Apply(ctx, d)
+		require.NoError(t, err)
+		require.True(t, applied)
+		return d
+	}
+	position := func(user string) *apis.Position {
+		t.Helper()
+		list, err := tr.Get(ctx, user)
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+		return list[0]
+	}
+
+	// user1 buys 10 at 100 and 10 at 110
+	deal("user1", "user2", 10, 100)
+	d := deal("user1", "user2", 10, 110)
+	p := position("user1")
+	require.Equal(t, int64(20), p.Amount)
+	require.Equal(t, int64(2100), p.Cost)
+	require.Equal(t, 105.0, p.AveragePrice)
+	require.Equal(t, int64(110), p.LastPrice)
+	require.Equal(t, int64(100), p.UnrealizedPnl)
+	require.Equal(t, int64(2), p.Fees)
+
+	// a redelivered deal is applied once
+	applied, err := tr.Apply(ctx, d)
+	require.NoError(t, err)
+	require.False(t, applied)
+
+	// selling 5 at 120 realizes 15 a unit over the average
+	deal("user3", "user1", 5, 120)
+	p = position("user1")
+	require.Equal(t, int64(15), p.Amount)
+	require.Equal(t, int64(75), p.RealizedPnl)
+	require.Equal(t, 105.0, p.AveragePrice)
+	require.Equal(t, int64(225), p.UnrealizedPnl)
+
+	// user2 is short 20 at 105 and the last price marks it down
+	p = position("user2")
+	require.Equal(t, int64(-20), p.Amount)
+	require.Equal(t, 105.0, p.AveragePrice)
+	require.Equal(t, int64(-300), p.UnrealizedPnl)
+
+	// selling 20 more closes the long and opens a short of 5 at 90
+	deal("user3", "user1", 20, 90)
+	p = position("user1")
+	require.Equal(t, int64(-5), p.Amount)
+	require.Equal(t, int64(-450), p.Cost)
+	require.Equal(t, 90.0, p.AveragePrice)
+	require.Equal(t, int64(75-225), p.RealizedPnl)
+	require.Equal(t, int64(0), p.UnrealizedPnl)
+
+	// buying back the short at 80 realizes 10 a unit
+	deal("user1", "user3", 5, 80)
+	p = position("user1")
+	require.Equal(t, int64(0), p.Amount)
+	require.Equal(t, int64(0), p.Cost)
+	require.Equal(t, int64(-150+50), p.RealizedPnl)
+
+	list, err := tr.Get(ctx, "nobody")
+	require.NoError(t, err)
+	require.Empty(t, list)
+}
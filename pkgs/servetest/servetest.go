@@ -0,0 +1,33 @@
+// Package servetest runs the grpc services of opentd in tests.
+package servetest
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type Server interface {
+	Serve(l net.Listener) error
+	Stop()
+}
+
+// Serve runs s on a free local port until the test ends and returns its
+// address. s must stop without an error.
+func Serve(t testing.TB, s Server) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- s.Serve(l)
+	}()
+	t.Cleanup(func() {
+		s.Stop()
+		require.NoError(t, <-errChan)
+	})
+	return l.Addr().String()
+}
@@ -0,0 +1,71 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/atgane/opentd/pkgs/engine"
+)
+
+type fileStore struct {
+	path string
+}
+
+func newFileStore(conf SnapshotConfig) (*fileStore, error) {
+	if conf.FilePath == "" {
+		return nil, fmt.Errorf("snapshot file path is required")
+	}
+
+	s := new(fileStore)
+	s.path = conf.FilePath
+	return s, nil
+}
+
+// Save writes to a temporary file first so a crash never leaves a torn snapshot.
+func (s *fileStore) Save(ctx context.Context, snap *engine.Snapshot) error {
+	b, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), s.path)
+}
+
+func (s *fileStore) Load(ctx context.Context) (*engine.Snapshot, error) {
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	snap := new(engine.Snapshot)
+	if err := json.Unmarshal(b, snap); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
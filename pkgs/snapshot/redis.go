@@ -0,0 +1,57 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/atgane/opentd/pkgs/engine"
+	"github.com/redis/go-redis/v9"
+)
+
+const defaultRedisKey = "opentd:dealer:snapshot"
+
+type redisStore struct {
+	redisClient *redis.Client
+	key         string
+}
+
+func newRedisStore(conf SnapshotConfig, redisClient *redis.Client) (*redisStore, error) {
+	if redisClient == nil {
+		return nil, fmt.Errorf("redis client is required")
+	}
+
+	s := new(redisStore)
+	s.redisClient = redisClient
+	s.key = conf.RedisKey
+	if s.key == "" {
+		s.key = defaultRedisKey
+	}
+	return s, nil
+}
+
+func (s *redisStore) Save(ctx context.Context, snap *engine.Snapshot) error {
+	b, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+
+	return s.redisClient.Set(ctx, s.key, b, 0).Err()
+}
+
+func (s *redisStore) Load(ctx context.Context) (*engine.Snapshot, error) {
+	b, err := s.redisClient.Get(ctx, s.key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	snap := new(engine.Snapshot)
+	if err := json.Unmarshal(b, snap); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
@@ -0,0 +1,39 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/atgane/opentd/pkgs/engine"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	REDIS = "redis"
+	FILE  = "file"
+)
+
+var ErrNotFound = errors.New("snapshot not found")
+
+type SnapshotConfig struct {
+	StoreType string
+	RedisKey  string
+	FilePath  string
+}
+
+type Store interface {
+	Save(ctx context.Context, s *engine.Snapshot) error
+	Load(ctx context.Context) (*engine.Snapshot, error)
+}
+
+func NewStore(conf SnapshotConfig, redisClient *redis.Client) (Store, error) {
+	switch conf.StoreType {
+	case REDIS:
+		return newRedisStore(conf, redisClient)
+	case FILE:
+		return newFileStore(conf)
+	}
+
+	return nil, fmt.Errorf("undefined snapshot store")
+}
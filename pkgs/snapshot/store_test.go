@@ -0,0 +1,96 @@
+package snapshot_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/atgane/opentd/apis"
+	"github.com/atgane/opentd/pkgs/engine"
+	"github.com/atgane/opentd/pkgs/events"
+	"github.com/atgane/opentd/pkgs/snapshot"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+)
+
+func order(t *testing.T, m *engine.MatchingEngine, id, user string, side apis.Side, amount, price int64) {
+	t.Helper()
+
+	e := cloudevents.NewEvent()
+	e.SetID(id)
+	e.SetSource(events.FrontendSource)
+	if side == apis.Side_BUY {
+		e.SetType(events.BuyType)
+		require.NoError(t, e.SetData(cloudevents.ApplicationJSON, &apis.BuyRequest{UserId: user, Target: "t", Amount: amount, Price: price}))
+		require.NoError(t, m.AddBuy(e))
+		return
+	}
+	e.SetType(events.SellType)
+	require.NoError(t, e.SetData(cloudevents.ApplicationJSON, &apis.SellRequest{UserId: user, Target: "t", Amount: amount, Price: price}))
+	require.NoError(t, m.AddSell(e))
+}
+
+func TestStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	dir := t.TempDir()
+
+	for _, conf := range []snapshot.SnapshotConfig{
+		{StoreType: snapshot.REDIS},
+		{StoreType: snapshot.FILE, FilePath: filepath.Join(dir, "snapshot.json")},
+	} {
+		t.Run(conf.StoreType, func(t *testing.T) {
+			ctx := context.Background()
+			s, err := snapshot.NewStore(conf, redisClient)
+			require.NoError(t, err)
+
+			_, err = s.Load(ctx)
+			require.ErrorIs(t, err, snapshot.ErrNotFound)
+
+			// the deal of b1 is still waiting to be streamed when the
+			// snapshot is taken
+			m, err := engine.NewMatchingEngine(engine.EngineConfig{})
+			require.NoError(t, err)
+			order(t, m, "s1", "user1", apis.Side_SELL, 2, 10)
+			order(t, m, "s2", "user2", apis.Side_SELL, 1, 11)
+			order(t, m, "b1", "user3", apis.Side_BUY, 1, 10)
+			order(t, m, "b2", "user3", apis.Side_BUY, 1, 9)
+			snap := m.Snapshot()
+			require.Len(t, snap.PendingDeals, 1)
+			require.NoError(t, s.Save(ctx, snap))
+
+			loaded, err := s.Load(ctx)
+			require.NoError(t, err)
+			restored, err := engine.NewMatchingEngine(engine.EngineConfig{})
+			require.NoError(t, err)
+			require.NoError(t, restored.Restore(loaded))
+			require.Equal(t, snap, restored.Snapshot())
+
+			// the restored book keeps trading where the snapshot left off
+			order(t, restored, "b3", "user4", apis.Side_BUY, 2, 11)
+			next := restored.Snapshot()
+			require.Equal(t, "b3", next.LastEventID)
+			require.Empty(t, next.Books[0].Asks)
+			require.Len(t, next.PendingDeals, 3)
+
+			// a later snapshot replaces the earlier one
+			require.NoError(t, s.Save(ctx, next))
+			loaded, err = s.Load(ctx)
+			require.NoError(t, err)
+			require.Equal(t, "b3", loaded.LastEventID)
+		})
+	}
+
+	// saving a file leaves no temporary file behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	_, err = snapshot.NewStore(snapshot.SnapshotConfig{StoreType: snapshot.FILE}, nil)
+	require.Error(t, err)
+	_, err = snapshot.NewStore(snapshot.SnapshotConfig{StoreType: "unknown"}, redisClient)
+	require.Error(t, err)
+}